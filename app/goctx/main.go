@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
 
 	"github.com/preminger/goctx/cmd/goctx"
 )
@@ -12,7 +13,9 @@ func main() {
 }
 
 func actualMain() int {
-	ctx := context.Background()
+	// Ctrl-C cancels loading and propagation before any file is written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	rootCmd := goctx.NewRootCmd(ctx)
 
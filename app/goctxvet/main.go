@@ -0,0 +1,14 @@
+// Command goctxvet runs goctx's context-chain checks as a standalone go/analysis checker.
+// It can be used directly or as a vet tool:
+//
+//	go vet -vettool=$(which goctxvet) ./...
+package main
+
+import (
+	"github.com/preminger/goctx/pkg/goctx/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,95 @@
+package goctx
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd() *cobra.Command {
+	checkCmd := &cobra.Command{
+		Use:   "check [packages]",
+		Short: "Report broken context chains without modifying any file",
+		Long: `Report broken context chains without modifying any file.
+
+The following are reported:
+  - a function with a ctx in scope calls an in-module function that creates its own
+    context via context.Background() or context.TODO();
+  - a context-accepting callee is passed context.Background() or context.TODO()
+    while a ctx is in scope;
+  - a function's context parameter is unused although one of its callees needs one.
+
+Boundaries (tests, --http handlers, --stop-at functions) follow the same rules as the
+rewriter. The command exits with a non-zero status when any issue is found.`,
+		Example: `  # Check every package of the module
+  goctx check
+
+  # Check a subtree only
+  goctx check ./internal/...`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			opts, _, err := optionsFromFlags(cmd, args)
+			if err != nil {
+				return err
+			}
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
+			}
+
+			slog.Debug("invoking check", slog.Any("patterns", args))
+
+			diags, err := goctx.Check(cmd.Context(), opts, args)
+			if err != nil {
+				return fmt.Errorf("checking context chains: %w", err)
+			}
+
+			for _, d := range diags {
+				d.Pos.Filename = relativeToWorkDir(d.Pos.Filename)
+				fmt.Fprintln(cmd.OutOrStdout(), d.String())
+			}
+
+			if len(diags) > 0 {
+				return fmt.Errorf("found %d broken context chain(s)", len(diags))
+			}
+
+			return nil
+		},
+	}
+
+	checkCmd.Flags().StringArray(OptNameStopAt, nil, "Terminating boundary (repeatable): a function in TARGET syntax, a package pattern (./internal/api/..., example.com/app/api/...) or re:REGEXP over fully qualified function names")
+	checkCmd.Flags().String(OptNameCtxName, goctx.VarNameCtx, "Name of the context parameters introduced by suggested fixes")
+	checkCmd.Flags().String(OptNameCtxType, goctx.ContextContext, "Context type, qualified by import path, that counts as a context in scope besides context.Context")
+	checkCmd.Flags().StringArray(OptNameInclude, nil, "Only report findings in files matching this glob relative to the module root (repeatable)")
+	checkCmd.Flags().StringArray(OptNameExclude, nil, "Never report findings in files matching this glob relative to the module root (repeatable)")
+	checkCmd.Flags().Bool(OptNameHTTP, false, "Treat http.HandlerFunc boundaries as having a ctx available via req.Context()")
+	checkCmd.Flags().StringSlice(OptNameWeb, nil, "Treat handlers of these web frameworks as having a ctx available via the request: gin, echo, fiber, chi (net/http)")
+	checkCmd.Flags().Bool(OptNameCLI, false, "Treat cobra Run/RunE/PreRunE functions and urfave/cli actions as having a ctx available via the command")
+	checkCmd.Flags().String(OptNameBoundaryRules, "", "YAML file of boundary rules mapping parameter types to ctx expressions")
+	checkCmd.Flags().StringArray(OptNameCtxSource, nil, "Treat an in-scope parameter or receiver field of this type as a ctx in scope (repeatable), TYPE=EXPR")
+	checkCmd.Flags().Bool(OptNameDefaultCtxSource, false, "Treat *http.Request, *cobra.Command and *testing.T/B/F values and context.Context fields in scope as a ctx in scope")
+	checkCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags', e.g. 'tag1,tag2' or '!exclude')")
+
+	return checkCmd
+}
+
+// relativeToWorkDir shortens an absolute filename to a path relative to the working directory
+// when possible, for friendlier diagnostics.
+func relativeToWorkDir(filename string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return filename
+	}
+	rel, err := filepath.Rel(wd, filename)
+	if err != nil || filepath.IsAbs(rel) {
+		return filename
+	}
+
+	return rel
+}
@@ -0,0 +1,123 @@
+package goctx
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the project configuration file",
+		Long: `Inspect the project configuration file.
+
+goctx reads its defaults from ` + goctx.ConfigFileYAML + ` or ` + goctx.ConfigFileTOML + ` at the root of the
+module containing the working directory. Command-line flags take precedence over it.`,
+	}
+
+	printCmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			cfg, path, err := goctx.LoadProjectConfig(".")
+			if err != nil {
+				return err //nolint:wrapcheck // Errors name the configuration file.
+			}
+			out, err := yaml.Marshal(cfg)
+			if err != nil {
+				return fmt.Errorf("encoding configuration: %w", err)
+			}
+
+			if path == "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "# no %s or %s found, using defaults\n", goctx.ConfigFileYAML, goctx.ConfigFileTOML)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "# %s\n", relativeToWorkDir(path))
+			}
+			fmt.Fprint(cmd.OutOrStdout(), string(out))
+
+			return nil
+		},
+	}
+	configCmd.AddCommand(printCmd)
+
+	return configCmd
+}
+
+// applyProjectConfig fills the options whose flags were not given on the command line from the
+// project configuration file, if any.
+func applyProjectConfig(cmd *cobra.Command, opts *goctx.Options) error {
+	cfg, path, err := goctx.LoadProjectConfig(opts.WorkDir)
+	if err != nil {
+		return err //nolint:wrapcheck // Errors name the configuration file.
+	}
+	if path == "" {
+		return nil
+	}
+	unset := func(name string) bool {
+		flag := cmd.Flags().Lookup(name)
+
+		return flag == nil || !flag.Changed
+	}
+
+	if unset(OptNameCtxName) {
+		opts.CtxName = cfg.CtxName
+	}
+	if unset(OptNameCtxType) {
+		opts.CtxType = cfg.CtxType
+	}
+	if unset(OptNameTags) {
+		opts.Tags = cfg.Tags
+	}
+	if unset(OptNameStopAt) {
+		opts.StopAts = cfg.StopAt
+	}
+	if unset(OptNameInclude) {
+		opts.Include = cfg.Include
+	}
+	if unset(OptNameExclude) {
+		opts.Exclude = cfg.Exclude
+	}
+	if unset(OptNameMainCtx) {
+		opts.MainCtx = cfg.MainCtx
+	}
+	if unset(OptNameHTTP) {
+		opts.HTML = cfg.HTTP
+	}
+	if unset(OptNameWeb) {
+		opts.Web = cfg.Web
+	}
+	if unset(OptNameCLI) {
+		opts.CLI = cfg.CLI
+	}
+	if unset(OptNameBoundaryRules) {
+		opts.BoundaryRules = cfg.Boundaries
+	}
+	if opts.Param == nil {
+		// The upgrades apply to context parameters only; goctx param rejects them.
+		if unset(OptNameUpgradeStdlib) {
+			opts.UpgradeStdlib = cfg.UpgradeStdlib
+		}
+		opts.StdlibUpgrades = cfg.StdlibUpgrades
+		if unset(OptNameUpgradeSlog) {
+			opts.UpgradeSlog = cfg.UpgradeSlog
+		}
+		if unset(OptNameContextVariants) {
+			opts.ContextVariants = cfg.ContextVariants
+		}
+	}
+	if unset(OptNameCtxSource) && unset(OptNameDefaultCtxSource) {
+		opts.ContextSources = cfg.ContextSources()
+	}
+	slog.Debug("project configuration applied", slog.String("path", path))
+
+	return nil
+}
@@ -1,9 +1,33 @@
 package goctx
 
 const (
+	OptNameBoundaryRules    = "boundary-rules"
+	OptNameCLI              = "cli"
+	OptNameCompatWrappers   = "compat-wrappers"
+	OptNameContextVariants  = "context-variants"
+	OptNameCtxName          = "ctx-name"
+	OptNameCtxSource        = "ctx-source"
+	OptNameCtxType          = "ctx-type"
+	OptNameDefaultCtxSource = "default-ctx-sources"
+	OptNameDryRun           = "dry-run"
+	OptNameExclude          = "exclude"
 	OptNameHTTP             = "http"
+	OptNameInclude          = "include"
+	OptNameMainCtx          = "main-ctx"
+	OptNameMaxChanges       = "max-changes"
+	OptNameMaxDepth         = "max-depth"
+	OptNameParamName        = "name"
+	OptNameParamRoot        = "root"
+	OptNameParamType        = "type"
+	OptNamePreserveExported = "preserve-exported"
+	OptNamePropagate        = "propagate"
+	OptNamePreserveExpr     = "preserve-exported-expr"
 	OptNameStopAt           = "stop-at"
 	OptNameTags             = "tags"
+	OptNameTargetsFile      = "targets-file"
+	OptNameUpgradeSlog      = "upgrade-slog"
+	OptNameUpgradeStdlib    = "upgrade-stdlib"
 	OptNameVerbose          = "verbose"
+	OptNameWeb              = "web"
 	OptNameVerboseShortHand = "v"
 )
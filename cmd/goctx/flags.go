@@ -0,0 +1,84 @@
+package goctx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+)
+
+// optionsFromFlags reads the flags cmd defines among those shared by the commands into
+// Options, and returns them with --dry-run. Flags cmd does not define keep their zero value.
+// With --targets-file, the targets are args followed by the targets read from the file.
+func optionsFromFlags(cmd *cobra.Command, args []string) (goctx.Options, bool, error) {
+	flags := cmd.Flags()
+	opts := goctx.Options{WorkDir: "."}
+	var dryRun bool
+	var targetsFile string
+
+	err := errors.Join(
+		readFlag(cmd, OptNameStopAt, flags.GetStringArray, &opts.StopAts),
+		readFlag(cmd, OptNameTags, flags.GetString, &opts.Tags),
+		readFlag(cmd, OptNameHTTP, flags.GetBool, &opts.HTML),
+		readFlag(cmd, OptNameCLI, flags.GetBool, &opts.CLI),
+		readFlag(cmd, OptNameWeb, flags.GetStringSlice, &opts.Web),
+		readFlag(cmd, OptNameCtxName, flags.GetString, &opts.CtxName),
+		readFlag(cmd, OptNameCtxType, flags.GetString, &opts.CtxType),
+		readFlag(cmd, OptNameMainCtx, flags.GetString, &opts.MainCtx),
+		readFlag(cmd, OptNameInclude, flags.GetStringArray, &opts.Include),
+		readFlag(cmd, OptNameExclude, flags.GetStringArray, &opts.Exclude),
+		readFlag(cmd, OptNamePreserveExported, flags.GetBool, &opts.PreserveExported),
+		readFlag(cmd, OptNamePreserveExpr, flags.GetString, &opts.PreserveExportedExpr),
+		readFlag(cmd, OptNameCompatWrappers, flags.GetBool, &opts.CompatWrappers),
+		readFlag(cmd, OptNameUpgradeStdlib, flags.GetBool, &opts.UpgradeStdlib),
+		readFlag(cmd, OptNameUpgradeSlog, flags.GetBool, &opts.UpgradeSlog),
+		readFlag(cmd, OptNameContextVariants, flags.GetBool, &opts.ContextVariants),
+		readFlag(cmd, OptNamePropagate, flags.GetBool, &opts.PropagateToLoggers),
+		readFlag(cmd, OptNameMaxDepth, flags.GetInt, &opts.MaxDepth),
+		readFlag(cmd, OptNameMaxChanges, flags.GetInt, &opts.MaxChanges),
+		readFlag(cmd, OptNameDryRun, flags.GetBool, &dryRun),
+		readFlag(cmd, OptNameTargetsFile, flags.GetString, &targetsFile),
+	)
+	if err != nil {
+		return opts, false, err
+	}
+
+	if flags.Lookup(OptNameBoundaryRules) != nil {
+		if opts.BoundaryRules, err = boundaryRulesFromFlag(cmd); err != nil {
+			return opts, false, err
+		}
+	}
+	if flags.Lookup(OptNameCtxSource) != nil {
+		if opts.ContextSources, err = contextSourcesFromFlags(cmd); err != nil {
+			return opts, false, err
+		}
+	}
+
+	if flags.Lookup(OptNameTargetsFile) != nil {
+		opts.Targets = args
+		if targetsFile != "" {
+			fromFile, err := readTargetsFile(cmd, targetsFile)
+			if err != nil {
+				return opts, false, err
+			}
+			opts.Targets = append(opts.Targets, fromFile...)
+		}
+	}
+
+	return opts, dryRun, nil
+}
+
+// readFlag stores the value of the flag name in dst with get, unless cmd does not define it.
+func readFlag[T any](cmd *cobra.Command, name string, get func(string) (T, error), dst *T) error {
+	if cmd.Flags().Lookup(name) == nil {
+		return nil
+	}
+	value, err := get(name)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+	*dst = value
+
+	return nil
+}
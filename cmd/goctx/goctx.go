@@ -1,9 +1,13 @@
 package goctx
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/charmbracelet/fang"
 	"github.com/charmbracelet/log"
@@ -16,108 +20,293 @@ const shortDescription = "Command-line Go utility that automatically adds missin
 
 func NewRootCmd(ctx context.Context) *cobra.Command {
 	rootCmd := &cobra.Command{
-		Use:   "goctx TARGET",
+		Use:   "goctx TARGET...",
 		Short: shortDescription,
 		Long: shortDescription + `
 
-TARGET is of the form:
+TARGET is one of:
   path/to/file.go:FuncName[:N]
+  path/to/file.go:Type.Method[:N]
+  path/to/file.go:(*Type).Method[:N]
+  path/to/file.go:#L42              (the declaration enclosing line 42)
+  path/to/file.go:42:7              (the declaration enclosing line 42, column 7)
+  example.com/pkg.Func
+  example.com/pkg.(*Type).Method
 
 Where N is the 1-based line number of the function/method declaration.
-If you omit N and multiple functions with the same name exist in the file,
-resolution is ambiguous and the tool will ask you to disambiguate by line number.`,
+A bare FuncName also matches methods of that name. If the target is ambiguous,
+the tool lists the candidates so you can pick one.
+
+Several targets can be given at once, as arguments and/or with --targets-file
+(one TARGET per line, '-' for stdin; blank lines and lines starting with '#' are
+ignored). The module is loaded once and all targets are handled in one run.`,
 		Example: `  # Target a function by name
   goctx ./internal/foo/bar.go:FuncInNeedOfContext
 
   # Disambiguate by 1-based line number of the declaration
   goctx ./internal/foo/bar.go:FuncInNeedOfContext:42
 
+  # Target a method by receiver, or by import path without knowing the file
+  goctx './internal/foo/bar.go:(*Client).Close'
+  goctx 'example.com/project/internal/foo.(*Client).Close'
+
+  # Several targets in one run
+  goctx ./internal/db/users.go:GetUser ./internal/db/users.go:ListUsers
+  goctx --targets-file targets.txt
+
+  # Print the intended edits without writing any file
+  goctx --dry-run ./internal/foo/bar.go:FuncInNeedOfContext
+
   # Stop propagation at another function (also supports :N)
   goctx --stop-at ./internal/foo/baz.go:FuncServingAsBoundary ./internal/foo/bar.go:FuncInNeedOfContext
 
+  # Stop at every function of the API packages and at every exported method of api.Server
+  goctx --stop-at ./internal/api/... --stop-at 're:^\(\*example\.com/app/api\.Server\)\.[A-Z]' ./internal/foo/bar.go:FuncInNeedOfContext
+
   NOTE: goctx will not work unless you have a 'go.mod' file.
   That's because it uses Go internals to parse your code into packages!`,
 		Version: version.OverallVersionStringColorized(ctx),
-		Args:    cobra.MaximumNArgs(1),
+		Args:    cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			logHandler := log.NewWithOptions(
-				cmd.OutOrStdout(),
-				log.Options{
-					Level:           log.WarnLevel, // Setting this to lowest possible value, since slog will handle the actual filtering.
-					ReportTimestamp: true,
-					ReportCaller:    true,
-				},
-			)
-			logger := slog.New(logHandler)
-			slog.SetDefault(logger)
-
-			slog.Debug("logger initialized")
-
-			stopAt, err := cmd.Root().Flags().GetString(OptNameStopAt)
-			if err != nil {
-				return fmt.Errorf("parsing stop-at: %w", err)
+			if err := initLogging(cmd); err != nil {
+				return err
 			}
 
-			tags, err := cmd.Root().Flags().GetString(OptNameTags)
+			opts, dryRun, err := optionsFromFlags(cmd, args)
 			if err != nil {
-				return fmt.Errorf("parsing tags: %w", err)
-			}
-
-			httpMode, err := cmd.Root().Flags().GetBool(OptNameHTTP)
-			if err != nil {
-				return fmt.Errorf("parsing html: %w", err)
-			}
-
-			verbose, err := cmd.Root().PersistentFlags().GetBool(OptNameVerbose)
-			if err != nil {
-				return fmt.Errorf("parsing verbose: %w", err)
-			}
-
-			if verbose {
-				logHandler.SetLevel(log.DebugLevel)
+				return err
 			}
 
 			slog.Debug(
 				"flags parsed",
-				slog.String("stopAt", stopAt),
-				slog.String("tags", tags),
-				slog.Bool("html", httpMode),
-				slog.Bool("verbose", verbose),
+				slog.Any("stopAt", opts.StopAts),
+				slog.String("tags", opts.Tags),
+				slog.Bool("http", opts.HTML),
+				slog.Bool("cli", opts.CLI),
+				slog.Any("web", opts.Web),
+				slog.String("mainCtx", opts.MainCtx),
+				slog.Bool("dryRun", dryRun),
+				slog.Bool("preserveExported", opts.PreserveExported),
+				slog.Bool("compatWrappers", opts.CompatWrappers),
+				slog.Int("maxDepth", opts.MaxDepth),
+				slog.Int("maxChanges", opts.MaxChanges),
 				slog.Int("argc", len(cmd.Flags().Args())),
 			)
 
-			if len(cmd.Flags().Args()) < 1 {
+			if len(opts.Targets) < 1 {
 				return cmd.Help()
 			}
 
-			opts := goctx.Options{
-				Target:  args[0],
-				StopAt:  stopAt,
-				Tags:    tags,
-				HTML:    httpMode,
-				WorkDir: ".",
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
 			}
 
 			slog.Debug(
 				"invoking run",
-				slog.String("target", opts.Target),
-				slog.String("stopAt", opts.StopAt),
+				slog.Any("targets", opts.Targets),
+				slog.Any("stopAt", opts.StopAts),
 				slog.Bool("html", opts.HTML),
 				slog.String("workDir", opts.WorkDir),
 			)
 
-			return goctx.Run(cmd.Context(), opts)
+			return runPlan(cmd, opts, dryRun)
 		},
 	}
 
-	rootCmd.Flags().String(OptNameStopAt, "", "Optional terminating function path of the form path/to/file.go:FuncName[:N]")
+	rootCmd.Flags().StringArray(OptNameStopAt, nil, "Terminating boundary (repeatable): a function in TARGET syntax, a package pattern (./internal/api/..., example.com/app/api/...) or re:REGEXP over fully qualified function names")
+	rootCmd.Flags().String(OptNameCtxName, goctx.VarNameCtx, "Name of the context parameters and variables goctx introduces")
+	rootCmd.Flags().String(OptNameCtxType, goctx.ContextContext, "Type of the context parameters goctx introduces, qualified by import path (e.g. 'example.com/app/appctx.Ctx'); must implement context.Context")
+	rootCmd.Flags().StringArray(OptNameInclude, nil, "Only modify files matching this glob relative to the module root (repeatable), e.g. 'internal/**'")
+	rootCmd.Flags().StringArray(OptNameExclude, nil, "Never modify files matching this glob relative to the module root (repeatable), e.g. '**/*_gen.go'")
 	rootCmd.Flags().Bool(OptNameHTTP, false, "Terminate at http.HandlerFunc boundaries and derive ctx from req.Context()")
+	rootCmd.Flags().StringSlice(OptNameWeb, nil, "Terminate at handlers of these web frameworks and derive ctx from the request: gin, echo, fiber, chi (net/http)")
+	rootCmd.Flags().Bool(OptNameCLI, false, "Terminate at cobra Run/RunE/PreRunE functions and urfave/cli actions and derive ctx from the command")
+	rootCmd.Flags().String(OptNameMainCtx, goctx.MainCtxBackground, "Root context created in main: background, todo, signal (signal.NotifyContext on SIGINT/SIGTERM) or timeout:<duration>")
+	rootCmd.Flags().String(OptNameBoundaryRules, "", "YAML file of boundary rules mapping parameter types to ctx expressions, e.g. '*github.com/spf13/cobra.Command' to '{{.Param}}.Context()'")
+	rootCmd.Flags().StringArray(OptNameCtxSource, nil, "Derive ctx at call sites from an in-scope parameter or receiver field of this type instead of adding a ctx parameter (repeatable), TYPE=EXPR, e.g. '*example.com/app/jobs.Job={{.Param}}.Ctx()'")
+	rootCmd.Flags().Bool(OptNameDefaultCtxSource, false, "Also derive ctx from *http.Request, *cobra.Command and *testing.T/B/F values and context.Context fields in scope")
+	rootCmd.Flags().Bool(OptNamePreserveExported, false, "Keep signatures of exported functions in non-internal library packages; pass a placeholder context there and report it as API debt")
+	rootCmd.Flags().String(OptNamePreserveExpr, goctx.ExprContextTODO, "Context expression passed at call sites inside preserved exported functions")
+	rootCmd.Flags().Bool(OptNameCompatWrappers, false, "Rename exported functions that gain a ctx to FooContext and keep Foo as a deprecated wrapper using context.Background()")
+	rootCmd.Flags().Bool(OptNameUpgradeStdlib, false, "Also switch calls such as http.NewRequest, (*sql.DB).Query or exec.Command in the modified functions to their context-aware variants")
+	rootCmd.Flags().Bool(OptNameUpgradeSlog, false, "Also switch slog.Info, (*slog.Logger).Error and the other level functions in the modified functions to their Context variants")
+	rootCmd.Flags().Bool(OptNameContextVariants, false, "Also switch calls such as Fetch(id) in the modified functions to FetchContext(ctx, id) when a package of the module declares both")
+	rootCmd.Flags().Int(OptNameMaxDepth, 0, "Stop propagation this many caller levels above the targets and pass context.TODO() at the cut (0 = unlimited)")
+	rootCmd.Flags().Int(OptNameMaxChanges, 0, "Abort without writing when the plan would modify more than this many functions (0 = unlimited)")
+	rootCmd.Flags().String(OptNameTargetsFile, "", "Read additional targets from this file, one per line ('-' for stdin)")
+	rootCmd.Flags().Bool(OptNameDryRun, false, "Print the intended edits (file:line, function and reason) without writing any file")
 	rootCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags', e.g. 'tag1,tag2' or '!exclude')")
 	rootCmd.PersistentFlags().BoolP(OptNameVerbose, OptNameVerboseShortHand, false, "Verbose output")
 
+	rootCmd.AddCommand(newCheckCmd())
+	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newParamCmd())
+	rootCmd.AddCommand(newRemoveCmd())
+	rootCmd.AddCommand(newUnstoreCmd())
+	rootCmd.AddCommand(newUpgradeStdlibCmd())
+	rootCmd.AddCommand(newUpgradeSlogCmd())
+
 	return rootCmd
 }
 
+// runPlan computes the plan for opts and prints it with dryRun, or writes it.
+func runPlan(cmd *cobra.Command, opts goctx.Options, dryRun bool) error {
+	plan, err := goctx.Plan(cmd.Context(), opts)
+	if err != nil {
+		return err //nolint:wrapcheck // Errors from Plan are already descriptive.
+	}
+
+	return applyPlan(cmd, plan, opts, dryRun)
+}
+
+// applyPlan prints the edits of plan with dryRun, or writes them, followed by the spots that
+// need attention.
+func applyPlan(cmd *cobra.Command, plan *goctx.ChangePlan, opts goctx.Options, dryRun bool) error {
+	if dryRun {
+		for _, edit := range plan.Edits {
+			edit.File = relativeToWorkDir(edit.File)
+			fmt.Fprintln(cmd.OutOrStdout(), edit.String())
+		}
+	} else if err := plan.Apply(cmd.Context(), goctx.OSFS{}); err != nil {
+		return fmt.Errorf("writing modified files: %w", err)
+	}
+	printAPIDebt(cmd, plan.Debt)
+	printTruncated(cmd, opts.MaxDepth, plan.Truncated)
+	printManual(cmd, plan.Manual)
+
+	return nil
+}
+
+// printAPIDebt lists the call sites where --preserve-exported kept an exported signature.
+func printAPIDebt(cmd *cobra.Command, debt []goctx.APIDebt) {
+	if len(debt) == 0 {
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "API debt (%d exported signature(s) preserved):\n", len(debt))
+	for _, d := range debt {
+		d.Pos.Filename = relativeToWorkDir(d.Pos.Filename)
+		fmt.Fprintln(cmd.OutOrStdout(), "  "+d.String())
+	}
+}
+
+// printTruncated lists the call sites where --max-depth cut the propagation.
+func printTruncated(cmd *cobra.Command, maxDepth int, truncated []goctx.Truncation) {
+	if len(truncated) == 0 {
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Propagation truncated at max depth %d (%d call site(s) passed %s):\n", maxDepth, len(truncated), truncated[0].Expr)
+	for _, t := range truncated {
+		t.Pos.Filename = relativeToWorkDir(t.Pos.Filename)
+		fmt.Fprintln(cmd.OutOrStdout(), "  "+t.String())
+	}
+}
+
+// printManual lists the calls without a context-aware variant that need a manual change.
+func printManual(cmd *cobra.Command, manual []goctx.ManualUpgrade) {
+	if len(manual) == 0 {
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Calls to change by hand (%d call(s) without a context-aware variant):\n", len(manual))
+	for _, m := range manual {
+		m.Pos.Filename = relativeToWorkDir(m.Pos.Filename)
+		fmt.Fprintln(cmd.OutOrStdout(), "  "+m.String())
+	}
+}
+
+// boundaryRulesFromFlag loads the boundary rules file named by --boundary-rules, if any.
+func boundaryRulesFromFlag(cmd *cobra.Command) ([]goctx.BoundaryRule, error) {
+	path, err := cmd.Flags().GetString(OptNameBoundaryRules)
+	if err != nil {
+		return nil, fmt.Errorf("parsing boundary-rules: %w", err)
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	return goctx.LoadBoundaryRules(path) //nolint:wrapcheck // Errors name the file and the rule.
+}
+
+// contextSourcesFromFlags parses --ctx-source and appends the defaults with --default-ctx-sources.
+func contextSourcesFromFlags(cmd *cobra.Command) ([]goctx.ContextSource, error) {
+	raw, err := cmd.Flags().GetStringArray(OptNameCtxSource)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ctx-source: %w", err)
+	}
+	defaults, err := cmd.Flags().GetBool(OptNameDefaultCtxSource)
+	if err != nil {
+		return nil, fmt.Errorf("parsing default-ctx-sources: %w", err)
+	}
+	sources := make([]goctx.ContextSource, 0, len(raw))
+	for _, r := range raw {
+		src, err := goctx.ParseContextSource(r)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // Errors name the source.
+		}
+		sources = append(sources, src)
+	}
+	if defaults {
+		sources = append(sources, goctx.DefaultContextSources...)
+	}
+
+	return sources, nil
+}
+
+// readTargetsFile reads one target per line from path, or from the command's stdin when path
+// is "-". Blank lines and lines starting with '#' are skipped.
+func readTargetsFile(cmd *cobra.Command, path string) ([]string, error) {
+	var input io.Reader = cmd.InOrStdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening targets file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var targets []string
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading targets file: %w", err)
+	}
+
+	return targets, nil
+}
+
+// initLogging installs the default slog logger, honoring the persistent --verbose flag.
+func initLogging(cmd *cobra.Command) error {
+	logHandler := log.NewWithOptions(
+		cmd.OutOrStdout(),
+		log.Options{
+			Level:           log.WarnLevel, // Setting this to lowest possible value, since slog will handle the actual filtering.
+			ReportTimestamp: true,
+			ReportCaller:    true,
+		},
+	)
+	logger := slog.New(logHandler)
+	slog.SetDefault(logger)
+
+	verbose, err := cmd.Root().PersistentFlags().GetBool(OptNameVerbose)
+	if err != nil {
+		return fmt.Errorf("parsing verbose: %w", err)
+	}
+
+	if verbose {
+		logHandler.SetLevel(log.DebugLevel)
+	}
+
+	slog.Debug("logger initialized", slog.Bool("verbose", verbose))
+
+	return nil
+}
+
 // ExecuteWithFang runs the root Cobra command with Fang-specific options.
 // It accepts a context and a root Cobra command as input parameters.
 // Returns an error if the command execution fails.
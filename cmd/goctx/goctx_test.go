@@ -1,9 +1,13 @@
 package goctx
 
 import (
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/preminger/goctx/pkg/goctx"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,3 +27,113 @@ func TestNoArgsPrintsHelp(t *testing.T) {
 	require.Regexp(t, `\bFLAGS\b`, stdoutBuf.String())
 	require.Empty(t, stderrBuf.String())
 }
+
+func TestReadTargetsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# db helpers\na.go:F\n\n  b.go:(*T).M  \n"), 0o644))
+
+	cmd := NewRootCmd(t.Context())
+	targets, err := readTargetsFile(cmd, path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.go:F", "b.go:(*T).M"}, targets)
+
+	cmd.SetIn(strings.NewReader("c.go:G\n"))
+	targets, err = readTargetsFile(cmd, "-")
+	require.NoError(t, err)
+	require.Equal(t, []string{"c.go:G"}, targets)
+
+	_, err = readTargetsFile(cmd, filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
+
+func TestConfigPrint(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".goctx.yaml"), []byte("tags: integration\nweb: [gin]\n"), 0o644))
+	t.Chdir(dir)
+
+	cmd := NewRootCmd(t.Context())
+	cmd.SetArgs([]string{"config", "print"})
+	var stdoutBuf strings.Builder
+	cmd.SetOut(&stdoutBuf)
+	require.NoError(t, cmd.Execute())
+
+	require.Contains(t, stdoutBuf.String(), "# .goctx.yaml\n")
+	require.Contains(t, stdoutBuf.String(), "ctx-name: ctx\n")
+	require.Contains(t, stdoutBuf.String(), "tags: integration\n")
+	require.Contains(t, stdoutBuf.String(), "web:\n    - gin\n")
+}
+
+func TestApplyProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".goctx.yaml"), []byte("ctx-name: c\ntags: integration\n"), 0o644))
+
+	cmd := NewRootCmd(t.Context())
+	opts := goctx.Options{WorkDir: dir}
+	require.NoError(t, applyProjectConfig(cmd, &opts))
+	require.Equal(t, "c", opts.CtxName)
+	require.Equal(t, "integration", opts.Tags)
+
+	// Flags given on the command line take precedence.
+	cmd = NewRootCmd(t.Context())
+	require.NoError(t, cmd.Flags().Set(OptNameCtxName, "rc"))
+	opts = goctx.Options{WorkDir: dir, CtxName: "rc"}
+	require.NoError(t, applyProjectConfig(cmd, &opts))
+	require.Equal(t, "rc", opts.CtxName)
+}
+
+func TestOptionsFromFlags(t *testing.T) {
+	targetsFile := filepath.Join(t.TempDir(), "targets.txt")
+	require.NoError(t, os.WriteFile(targetsFile, []byte("./b.go:B\n"), 0o644))
+
+	cmd := NewRootCmd(t.Context())
+	require.NoError(t, cmd.Flags().Set(OptNameHTTP, "true"))
+	require.NoError(t, cmd.Flags().Set(OptNameMaxDepth, "2"))
+	require.NoError(t, cmd.Flags().Set(OptNameDryRun, "true"))
+	require.NoError(t, cmd.Flags().Set(OptNameTargetsFile, targetsFile))
+	opts, dryRun, err := optionsFromFlags(cmd, []string{"./a.go:A"})
+	require.NoError(t, err)
+	require.True(t, dryRun)
+	require.True(t, opts.HTML)
+	require.Equal(t, 2, opts.MaxDepth)
+	require.Equal(t, goctx.ContextContext, opts.CtxType)
+	require.Equal(t, []string{"./a.go:A", "./b.go:B"}, opts.Targets)
+
+	// Flags the command does not define keep their zero value, and without --targets-file
+	// the arguments are not targets.
+	cmd = newUnstoreCmd()
+	opts, _, err = optionsFromFlags(cmd, []string{"Worker.ctx"})
+	require.NoError(t, err)
+	require.Empty(t, opts.CtxType)
+	require.Empty(t, opts.Targets)
+	require.Equal(t, goctx.VarNameCtx, opts.CtxName)
+}
+
+func TestParamIgnoresContextUpgradesFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".goctx.yaml"), []byte("upgrade-stdlib: true\nupgrade-slog: true\ncontext-variants: true\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import "database/sql"
+
+func insert() {}
+
+func main() {
+	var tx *sql.Tx
+	_ = tx
+	insert()
+}
+`), 0o644))
+	t.Chdir(dir)
+
+	cmd := NewRootCmd(t.Context())
+	cmd.SetArgs([]string{"param", "--type", "database/sql.*Tx", "--name", "tx", "--dry-run", "./main.go:insert"})
+	var stdoutBuf strings.Builder
+	cmd.SetOut(&stdoutBuf)
+	cmd.SetErr(io.Discard)
+
+	require.NoError(t, cmd.Execute())
+	require.Contains(t, stdoutBuf.String(), "insert")
+}
@@ -0,0 +1,94 @@
+package goctx
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+)
+
+func newParamCmd() *cobra.Command {
+	paramCmd := &cobra.Command{
+		Use:   "param --type TYPE --name NAME TARGET...",
+		Short: "Thread a parameter of any type, such as a *sql.Tx, along the call graph",
+		Long: `Thread a parameter of any type, such as a *sql.Tx or a *slog.Logger, along the call-graph
+leading to TARGET, exactly as goctx does for context.Context.
+
+TYPE is qualified by import path: 'database/sql.*Tx', '*database/sql.Tx' or
+'go.opentelemetry.io/otel/trace.Span'. A caller that already has a value of TYPE in
+scope passes it; the others gain a NAME parameter in turn. At boundaries (main, tests,
+--http handlers, --stop-at functions) NAME is set to the --root expression, printed
+verbatim; without --root, reaching main, a test or a handler is an error and stop-at
+functions are left for you to complete.`,
+		Example: `  # Thread a transaction from the handlers down to the repository
+  goctx param --type 'database/sql.*Tx' --name tx --http --root 'db.MustBegin()' ./internal/repo/users.go:Insert
+
+  # Thread a logger, stopping at the service layer
+  goctx param --type 'log/slog.*Logger' --name logger --stop-at ./internal/svc/... ./internal/repo/users.go:Insert`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			paramType, err := cmd.Flags().GetString(OptNameParamType)
+			if err != nil {
+				return fmt.Errorf("parsing type: %w", err)
+			}
+
+			paramName, err := cmd.Flags().GetString(OptNameParamName)
+			if err != nil {
+				return fmt.Errorf("parsing name: %w", err)
+			}
+
+			root, err := cmd.Flags().GetString(OptNameParamRoot)
+			if err != nil {
+				return fmt.Errorf("parsing root: %w", err)
+			}
+
+			opts, dryRun, err := optionsFromFlags(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			if len(opts.Targets) < 1 {
+				return cmd.Help()
+			}
+			if paramType == "" || paramName == "" {
+				return errors.New("param needs both --type and --name")
+			}
+
+			opts.Param = &goctx.ParamSpec{Type: paramType, Name: paramName, Root: root}
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
+			}
+
+			slog.Debug(
+				"invoking param",
+				slog.Any("targets", opts.Targets),
+				slog.String("type", paramType),
+				slog.String("name", paramName),
+				slog.String("root", root),
+			)
+
+			return runPlan(cmd, opts, dryRun)
+		},
+	}
+
+	paramCmd.Flags().String(OptNameParamType, "", "Parameter type qualified by import path, e.g. 'database/sql.*Tx'")
+	paramCmd.Flags().String(OptNameParamName, "", "Parameter name, e.g. tx")
+	paramCmd.Flags().String(OptNameParamRoot, "", "Expression the parameter is set to at boundaries and where --max-depth cuts propagation, e.g. 'db.MustBegin()'")
+	paramCmd.Flags().StringArray(OptNameStopAt, nil, "Terminating boundary (repeatable), with the same syntax as for goctx")
+	paramCmd.Flags().Bool(OptNameHTTP, false, "Terminate at http.HandlerFunc boundaries")
+	paramCmd.Flags().StringArray(OptNameInclude, nil, "Only modify files matching this glob relative to the module root (repeatable)")
+	paramCmd.Flags().StringArray(OptNameExclude, nil, "Never modify files matching this glob relative to the module root (repeatable)")
+	paramCmd.Flags().Int(OptNameMaxDepth, 0, "Stop propagation this many caller levels above the targets and pass the --root expression at the cut (0 = unlimited)")
+	paramCmd.Flags().Int(OptNameMaxChanges, 0, "Abort without writing when the plan would modify more than this many functions (0 = unlimited)")
+	paramCmd.Flags().String(OptNameTargetsFile, "", "Read additional targets from this file, one per line ('-' for stdin)")
+	paramCmd.Flags().Bool(OptNameDryRun, false, "Print the intended edits without writing any file")
+	paramCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags')")
+
+	return paramCmd
+}
@@ -0,0 +1,76 @@
+package goctx
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+)
+
+func newRemoveCmd() *cobra.Command {
+	removeCmd := &cobra.Command{
+		Use:   "remove TARGET...",
+		Short: "Remove a context parameter that is not needed, and its arguments",
+		Long: `Remove the context parameter of each TARGET, the reverse of goctx.
+
+The parameter must be unused in the body of TARGET, or only passed on to functions
+whose context parameter is removed too. Starting at TARGET, goctx walks down the
+functions it passes the context to and up the callers that pass their own context
+parameter to it, and removes the parameter wherever it is no longer needed. Every call
+site drops the argument, context variables left unused (such as ctx :=
+context.Background() in main) are removed, and so are unused imports of "context".
+
+Functions used as values, methods implementing an interface and boundaries (main,
+tests, --http handlers, --stop-at functions, ...) keep their signature. TARGET has the
+same syntax as for goctx.`,
+		Example: `  # Undo the plumbing of ctx into a helper that never used it
+  goctx remove ./internal/store/store.go:Get
+
+  # Preview the edits
+  goctx remove --dry-run example.com/app/internal/store.Get`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			opts, dryRun, err := optionsFromFlags(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			if len(opts.Targets) < 1 {
+				return cmd.Help()
+			}
+
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
+			}
+
+			slog.Debug("invoking remove", slog.Any("targets", opts.Targets), slog.Any("stopAt", opts.StopAts))
+
+			plan, err := goctx.Remove(cmd.Context(), opts)
+			if err != nil {
+				return fmt.Errorf("removing context parameters: %w", err)
+			}
+
+			return applyPlan(cmd, plan, opts, dryRun)
+		},
+	}
+
+	removeCmd.Flags().StringArray(OptNameStopAt, nil, "Function whose signature is kept (repeatable), with the same syntax as for goctx")
+	removeCmd.Flags().String(OptNameCtxType, goctx.ContextContext, "Context type, qualified by import path, whose parameters are removed besides context.Context")
+	removeCmd.Flags().StringArray(OptNameInclude, nil, "Only modify files matching this glob relative to the module root (repeatable)")
+	removeCmd.Flags().StringArray(OptNameExclude, nil, "Never modify files matching this glob relative to the module root (repeatable)")
+	removeCmd.Flags().Bool(OptNameHTTP, false, "Keep the signature of http.HandlerFunc functions")
+	removeCmd.Flags().StringSlice(OptNameWeb, nil, "Keep the signature of handlers of these web frameworks: gin, echo, fiber, chi (net/http)")
+	removeCmd.Flags().Bool(OptNameCLI, false, "Keep the signature of cobra Run/RunE/PreRunE functions and urfave/cli actions")
+	removeCmd.Flags().String(OptNameBoundaryRules, "", "YAML file of boundary rules; functions matching a rule keep their signature")
+	removeCmd.Flags().Int(OptNameMaxChanges, 0, "Abort without writing when the plan would modify more than this many functions (0 = unlimited)")
+	removeCmd.Flags().String(OptNameTargetsFile, "", "Read additional targets from this file, one per line ('-' for stdin)")
+	removeCmd.Flags().Bool(OptNameDryRun, false, "Print the intended edits without writing any file")
+	removeCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags')")
+
+	return removeCmd
+}
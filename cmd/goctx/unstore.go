@@ -0,0 +1,67 @@
+package goctx
+
+import (
+	"log/slog"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+)
+
+func newUnstoreCmd() *cobra.Command {
+	unstoreCmd := &cobra.Command{
+		Use:   "unstore Type.field",
+		Short: "Replace a context stored in a struct field with context parameters",
+		Long: `Replace a context stored in a struct field with context parameters.
+
+The field is removed, and so are the values stored in it by struct literals and
+assignments. Every function reading the field gets a ctx parameter instead, and its
+callers are updated exactly as goctx does for a TARGET. Constructors that only stored
+the context drop their ctx parameter, and their callers the argument.
+
+Type.field may be qualified by the import path of the package declaring Type when the
+type name is ambiguous, e.g. 'example.com/app/worker.Worker.ctx'.`,
+		Example: `  # Migrate Worker.ctx, deriving ctx from requests in HTTP handlers
+  goctx unstore --http Worker.ctx
+
+  # Preview the edits
+  goctx unstore --dry-run example.com/app/worker.Worker.ctx`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			opts, dryRun, err := optionsFromFlags(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			opts.Unstore = args[0]
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
+			}
+
+			slog.Debug("invoking unstore", slog.String("field", opts.Unstore), slog.Any("stopAt", opts.StopAts))
+
+			return runPlan(cmd, opts, dryRun)
+		},
+	}
+
+	unstoreCmd.Flags().StringArray(OptNameStopAt, nil, "Terminating boundary (repeatable), with the same syntax as for goctx")
+	unstoreCmd.Flags().String(OptNameCtxName, goctx.VarNameCtx, "Name of the context parameters and variables goctx introduces")
+	unstoreCmd.Flags().StringArray(OptNameInclude, nil, "Only modify files matching this glob relative to the module root (repeatable)")
+	unstoreCmd.Flags().StringArray(OptNameExclude, nil, "Never modify files matching this glob relative to the module root (repeatable)")
+	unstoreCmd.Flags().Bool(OptNameHTTP, false, "Terminate at http.HandlerFunc boundaries and derive ctx from req.Context()")
+	unstoreCmd.Flags().StringSlice(OptNameWeb, nil, "Terminate at handlers of these web frameworks and derive ctx from the request: gin, echo, fiber, chi (net/http)")
+	unstoreCmd.Flags().Bool(OptNameCLI, false, "Terminate at cobra Run/RunE/PreRunE functions and urfave/cli actions and derive ctx from the command")
+	unstoreCmd.Flags().String(OptNameMainCtx, goctx.MainCtxBackground, "Root context created in main: background, todo, signal or timeout:<duration>")
+	unstoreCmd.Flags().String(OptNameBoundaryRules, "", "YAML file of boundary rules mapping parameter types to ctx expressions")
+	unstoreCmd.Flags().StringArray(OptNameCtxSource, nil, "Derive ctx at call sites from an in-scope value of this type (repeatable), TYPE=EXPR")
+	unstoreCmd.Flags().Bool(OptNameDefaultCtxSource, false, "Also derive ctx from *http.Request, *cobra.Command and *testing.T/B/F values and context.Context fields in scope")
+	unstoreCmd.Flags().Int(OptNameMaxDepth, 0, "Stop propagation this many caller levels above the readers of the field and pass context.TODO() at the cut (0 = unlimited)")
+	unstoreCmd.Flags().Int(OptNameMaxChanges, 0, "Abort without writing when the plan would modify more than this many functions (0 = unlimited)")
+	unstoreCmd.Flags().Bool(OptNameDryRun, false, "Print the intended edits without writing any file")
+	unstoreCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags')")
+
+	return unstoreCmd
+}
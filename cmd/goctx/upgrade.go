@@ -0,0 +1,128 @@
+package goctx
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/spf13/cobra"
+)
+
+func newUpgradeStdlibCmd() *cobra.Command {
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade-stdlib [packages]",
+		Short: "Switch standard-library calls to their context-aware variants where a ctx is in scope",
+		Long: `Switch standard-library calls to their context-aware variants in every function that
+has a ctx in scope: http.NewRequest becomes http.NewRequestWithContext, (*sql.DB).Query
+becomes QueryContext, exec.Command becomes exec.CommandContext, net.Dial dials with a
+net.Dialer, and so on. Packages default to the whole module.
+
+Calls without a single-expression variant, such as http.Get or signal.Notify, are listed
+for a manual change. The mapping table can be extended with 'stdlib-upgrades' in the
+project configuration file.
+
+With --context-variants, calls such as Fetch(id) become FetchContext(ctx, id) when a
+package of the module declares both, FetchContext taking a context followed by the
+parameters of Fetch and returning the same results.`,
+		Example: `  # Preview the upgrades in the whole module
+  goctx upgrade-stdlib --dry-run
+
+  # Upgrade one package tree
+  goctx upgrade-stdlib ./internal/store/...`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			opts, dryRun, err := optionsFromFlags(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
+			}
+
+			slog.Debug("invoking upgrade-stdlib", slog.Any("patterns", args))
+
+			plan, err := goctx.UpgradeStdlib(cmd.Context(), opts, args)
+			if err != nil {
+				return fmt.Errorf("upgrading stdlib calls: %w", err)
+			}
+
+			return applyPlan(cmd, plan, opts, dryRun)
+		},
+	}
+
+	upgradeCmd.Flags().String(OptNameCtxType, goctx.ContextContext, "Context type, qualified by import path, that counts as a context in scope besides context.Context")
+	upgradeCmd.Flags().StringArray(OptNameInclude, nil, "Only modify files matching this glob relative to the module root (repeatable)")
+	upgradeCmd.Flags().StringArray(OptNameExclude, nil, "Never modify files matching this glob relative to the module root (repeatable)")
+	upgradeCmd.Flags().Bool(OptNameContextVariants, false, "Also switch calls such as Fetch(id) to FetchContext(ctx, id) when a package of the module declares both")
+	upgradeCmd.Flags().Bool(OptNameDryRun, false, "Print the intended edits without writing any file")
+	upgradeCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags')")
+
+	return upgradeCmd
+}
+
+func newUpgradeSlogCmd() *cobra.Command {
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade-slog [packages]",
+		Short: "Switch slog calls to their Context variants where a ctx is in scope",
+		Long: `Switch slog.Debug, slog.Info, slog.Warn and slog.Error, and the same methods of
+*slog.Logger, to DebugContext, InfoContext, WarnContext and ErrorContext in every function
+that has a ctx in scope, so that handlers see the trace and request values of the context.
+Packages default to the whole module.
+
+With --propagate, the functions of those packages that log without a ctx in scope get one
+first: they gain a ctx parameter, which is propagated to their callers exactly as goctx does
+for a TARGET, and boundaries such as main or HTTP handlers derive it.`,
+		Example: `  # Preview the upgrades in the whole module
+  goctx upgrade-slog --dry-run
+
+  # Also thread ctx into the functions of one package tree that log without one
+  goctx upgrade-slog --propagate --http ./internal/...`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initLogging(cmd); err != nil {
+				return err
+			}
+
+			opts, dryRun, err := optionsFromFlags(cmd, args)
+			if err != nil {
+				return err
+			}
+
+			if err := applyProjectConfig(cmd, &opts); err != nil {
+				return err
+			}
+
+			slog.Debug("invoking upgrade-slog", slog.Any("patterns", args), slog.Bool("propagate", opts.PropagateToLoggers))
+
+			plan, err := goctx.UpgradeSlog(cmd.Context(), opts, args)
+			if err != nil {
+				return fmt.Errorf("upgrading slog calls: %w", err)
+			}
+
+			return applyPlan(cmd, plan, opts, dryRun)
+		},
+	}
+
+	upgradeCmd.Flags().Bool(OptNamePropagate, false, "Give the functions that log without a ctx in scope a ctx parameter and propagate it to their callers")
+	upgradeCmd.Flags().StringArray(OptNameStopAt, nil, "Terminating boundary of the propagation (repeatable), with the same syntax as for goctx")
+	upgradeCmd.Flags().String(OptNameCtxName, goctx.VarNameCtx, "Name of the context parameters and variables goctx introduces")
+	upgradeCmd.Flags().String(OptNameCtxType, goctx.ContextContext, "Type of the context parameters goctx introduces, qualified by import path; must implement context.Context")
+	upgradeCmd.Flags().StringArray(OptNameInclude, nil, "Only modify files matching this glob relative to the module root (repeatable)")
+	upgradeCmd.Flags().StringArray(OptNameExclude, nil, "Never modify files matching this glob relative to the module root (repeatable)")
+	upgradeCmd.Flags().Bool(OptNameHTTP, false, "Terminate at http.HandlerFunc boundaries and derive ctx from req.Context()")
+	upgradeCmd.Flags().StringSlice(OptNameWeb, nil, "Terminate at handlers of these web frameworks and derive ctx from the request: gin, echo, fiber, chi (net/http)")
+	upgradeCmd.Flags().Bool(OptNameCLI, false, "Terminate at cobra Run/RunE/PreRunE functions and urfave/cli actions and derive ctx from the command")
+	upgradeCmd.Flags().String(OptNameMainCtx, goctx.MainCtxBackground, "Root context created in main: background, todo, signal or timeout:<duration>")
+	upgradeCmd.Flags().String(OptNameBoundaryRules, "", "YAML file of boundary rules mapping parameter types to ctx expressions")
+	upgradeCmd.Flags().Int(OptNameMaxDepth, 0, "Stop propagation this many caller levels above the logging functions and pass context.TODO() at the cut (0 = unlimited)")
+	upgradeCmd.Flags().Int(OptNameMaxChanges, 0, "Abort without writing when the plan would modify more than this many functions (0 = unlimited)")
+	upgradeCmd.Flags().Bool(OptNameDryRun, false, "Print the intended edits without writing any file")
+	upgradeCmd.Flags().StringP(OptNameTags, "t", "", "List of build tags to consider during loading (same syntax as 'go build -tags')")
+
+	return upgradeCmd
+}
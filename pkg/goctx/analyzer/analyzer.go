@@ -0,0 +1,158 @@
+// Package analyzer exposes goctx's context-chain checks as a go/analysis Analyzer, so they can
+// run under `go vet -vettool`, golangci-lint, gopls and other analysis drivers. Each diagnostic
+// carries the suggested fixes goctx would apply to the call site and, for same-package callees,
+// the callee's signature.
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/preminger/goctx/pkg/goctx"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+const doc = `report missing context.Context plumbing
+
+The goctx analyzer reports functions that have a context in scope but call a
+function creating its own root context (context.Background() or context.TODO()),
+context-accepting callees passed a root context while a ctx is in scope, and
+context parameters that are unused although a callee needs one.`
+
+// Analyzer is the goctx analyzer with default settings.
+var Analyzer = New(goctx.Options{}) //nolint:gochecknoglobals // Conventional entry point for analysis drivers.
+
+// createsContextFact marks a function that creates its own root context instead of accepting one.
+// Module records the declaring module so that findings stay within the analyzed module.
+type createsContextFact struct {
+	Module string
+}
+
+func (*createsContextFact) AFact() {}
+
+func (f *createsContextFact) String() string { return "createsContext" }
+
+// New returns an Analyzer using opts for boundary evaluation (HTML, StopAt, StopAts) and
+// suggested fixes (CtxName). The returned analyzer also registers -http, -ctx-name and a
+// repeatable -stop-at flag; -stop-at values are added to opts.StopAts.
+func New(opts goctx.Options) *analysis.Analyzer {
+	settings := opts
+	analyzer := &analysis.Analyzer{
+		Name:      "goctx",
+		Doc:       doc,
+		URL:       "https://github.com/preminger/goctx",
+		FactTypes: []analysis.Fact{new(createsContextFact)},
+		Run: func(pass *analysis.Pass) (any, error) {
+			return nil, run(pass, settings)
+		},
+	}
+	analyzer.Flags.BoolVar(&settings.HTML, "http", settings.HTML, "treat http.HandlerFunc boundaries as having a ctx available via req.Context()")
+	analyzer.Flags.Var((*stopAtFlag)(&settings.StopAts), "stop-at", "terminating boundary (repeatable): a function path/to/file.go:FuncName[:N], a package pattern or re:REGEXP")
+	analyzer.Flags.StringVar(&settings.CtxName, "ctx-name", settings.CtxName, "name of context parameters introduced by suggested fixes (default ctx)")
+
+	return analyzer
+}
+
+// stopAtFlag is a repeatable flag.Value collecting stop-at boundaries.
+type stopAtFlag []string
+
+func (f *stopAtFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return strings.Join(*f, ",")
+}
+
+func (f *stopAtFlag) Set(value string) error {
+	*f = append(*f, value)
+
+	return nil
+}
+
+func run(pass *analysis.Pass, opts goctx.Options) error {
+	pkg := &packages.Package{
+		ID:        pass.Pkg.Path(),
+		Name:      pass.Pkg.Name(),
+		PkgPath:   pass.Pkg.Path(),
+		Fset:      pass.Fset,
+		Syntax:    pass.Files,
+		Types:     pass.Pkg,
+		TypesInfo: pass.TypesInfo,
+	}
+	module := modulePath(pass)
+
+	// Export facts first so that same-package callees are recognized below.
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !goctx.CreatesOwnContext(fn, pkg) {
+				continue
+			}
+			if obj := pass.TypesInfo.Defs[fn.Name]; obj != nil {
+				pass.ExportObjectFact(obj, &createsContextFact{Module: module})
+			}
+		}
+	}
+
+	createsCtx := func(obj types.Object) bool {
+		var fact createsContextFact
+		if obj == nil || !pass.ImportObjectFact(obj, &fact) {
+			return false
+		}
+		// Without module information, fall back to same-package callees only.
+		if fact.Module == "" || module == "" {
+			return obj.Pkg() == pass.Pkg
+		}
+
+		return fact.Module == module
+	}
+
+	diags, err := goctx.CheckPackage(pkg, opts, createsCtx)
+	if err != nil {
+		return fmt.Errorf("checking %s: %w", pass.Pkg.Path(), err)
+	}
+	for _, d := range diags {
+		pass.Report(toAnalysisDiagnostic(d))
+	}
+
+	return nil
+}
+
+func modulePath(pass *analysis.Pass) string {
+	if pass.Module == nil {
+		return ""
+	}
+
+	return pass.Module.Path
+}
+
+func toAnalysisDiagnostic(d goctx.Diagnostic) analysis.Diagnostic {
+	out := analysis.Diagnostic{
+		Pos:      d.Start,
+		End:      d.End,
+		Category: string(d.Rule),
+		Message:  d.Message,
+	}
+	for _, fix := range d.Fixes {
+		sf := analysis.SuggestedFix{Message: fix.Message}
+		for _, e := range fix.Edits {
+			sf.TextEdits = append(sf.TextEdits, analysis.TextEdit{Pos: e.Pos, End: orPos(e.End, e.Pos), NewText: []byte(e.NewText)})
+		}
+		out.SuggestedFixes = append(out.SuggestedFixes, sf)
+	}
+
+	return out
+}
+
+func orPos(p, fallback token.Pos) token.Pos {
+	if p.IsValid() {
+		return p
+	}
+
+	return fallback
+}
@@ -0,0 +1,57 @@
+package a
+
+import "context"
+
+// fetch creates its own root context instead of accepting one.
+func fetch(id int) string { // want fetch:"createsContext"
+	ctx := context.Background()
+	return load(ctx, id)
+}
+
+func load(ctx context.Context, id int) string {
+	_ = ctx
+	_ = id
+	return "x"
+}
+
+func handle(ctx context.Context) {
+	_ = ctx
+	_ = fetch(1) // want `handle calls a.fetch, which creates its own root context; pass ctx instead`
+}
+
+func handleTODO(ctx context.Context) {
+	_ = ctx
+	_ = load(context.TODO(), 2) // want `handleTODO passes a root context to a.load although a ctx is in scope`
+}
+
+func ignoresCtx(_ context.Context) {
+	_ = load(context.Background(), 3) // want `context parameter "_" of ignoresCtx is unused, but callee a.load needs a context`
+}
+
+func fine(ctx context.Context) {
+	_ = load(ctx, 4)
+}
+
+type key struct{}
+
+// fetchValue derives its context from a root one under the name of the new parameter.
+func fetchValue(id int) string { // want fetchValue:"createsContext"
+	ctx := context.WithValue(context.Background(), key{}, id)
+	return load(ctx, id)
+}
+
+func handleValue(ctx context.Context) {
+	_ = ctx
+	_ = fetchValue(5) // want `handleValue calls a.fetchValue, which creates its own root context; pass ctx instead`
+}
+
+// fetchVar declares its context with var, which the fix cannot turn into an assignment.
+func fetchVar(id int) string { // want fetchVar:"createsContext"
+	var ctx = context.WithValue(context.Background(), key{}, id)
+	return load(ctx, id)
+}
+
+func handleVar(ctx context.Context) {
+	_ = ctx
+	_ = fetchVar(6) // want `handleVar calls a.fetchVar, which creates its own root context; pass ctx instead`
+}
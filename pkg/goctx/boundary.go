@@ -7,6 +7,8 @@ import (
 	"go/token"
 	"go/types"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -19,31 +21,48 @@ const (
 	StopReasonHTTP
 	StopReasonTest
 	StopReasonStopAt
+	StopReasonRule
+	StopReasonCLI
 )
 
+// String returns a short, human-readable name of the boundary kind.
+func (r StopReason) String() string {
+	switch r {
+	case StopReasonMain:
+		return "main"
+	case StopReasonHTTP:
+		return "http"
+	case StopReasonTest:
+		return "test"
+	case StopReasonStopAt:
+		return "stop-at"
+	case StopReasonRule:
+		return "rule"
+	case StopReasonCLI:
+		return "cli"
+	case StopReasonNone:
+	}
+
+	return "none"
+}
+
 // shouldStopAt evaluates termination conditions for the given enclosing function.
 // Returns (true, reason) when we should not propagate further upward.
-func shouldStopAt(funcDecl *ast.FuncDecl, pkg *packages.Package, opts Options, stopSpec *targetSpec) (bool, StopReason, error) {
+func shouldStopAt(funcDecl *ast.FuncDecl, pkg *packages.Package, opts Options, stops *stopSet) (bool, StopReason, error) {
 	// stop-at specific
-	if stopSpec != nil {
-		isSameFile, err := sameFile(pkg, funcDecl, stopSpec)
-		if err != nil {
-			return false, StopReasonNone, fmt.Errorf("determining if stop-at is in same file: %w", err)
-		}
+	spec, ok, err := stops.matches(pkg, funcDecl)
+	if err != nil {
+		return false, StopReasonNone, fmt.Errorf("matching stop-at boundaries: %w", err)
+	}
+	if ok {
+		slog.Debug("stopAt matched", slog.String("func", funcDecl.Name.Name), slog.String("spec", spec))
+		return true, StopReasonStopAt, nil
+	}
 
-		if isSameFile && funcDecl.Name.Name == stopSpec.FuncName {
-			// If no line number was provided, any matching function name in the file qualifies
-			if stopSpec.LineNumber < 1 {
-				slog.Debug("stopAt matched by name", slog.String("func", funcDecl.Name.Name))
-				return true, StopReasonStopAt, nil
-			}
-			// When a line number was provided, ensure it matches the function's starting line
-			start := pkg.Fset.Position(funcDecl.Pos()).Line
-			if start == stopSpec.LineNumber {
-				slog.Debug("stopAt matched by line", slog.String("func", funcDecl.Name.Name), slog.Int("line", start))
-				return true, StopReasonStopAt, nil
-			}
-		}
+	// configured boundary rules and CLI frameworks: any function with a parameter of a listed type
+	if reason, ok := boundaryRuleReason(funcDecl, pkg, opts); ok {
+		slog.Debug("stop at boundary rule", slog.String("func", funcDecl.Name.Name), slog.String("reason", reason.String()))
+		return true, reason, nil
 	}
 
 	// testing boundary: any function with testing.T, testing.B, testing.F, or testing.TB (or pointer)
@@ -53,7 +72,7 @@ func shouldStopAt(funcDecl *ast.FuncDecl, pkg *packages.Package, opts Options, s
 	}
 
 	// html handler boundary
-	if opts.HTML {
+	if opts.httpHandlers() {
 		if isHTTPHandlerFunc(funcDecl, pkg) {
 			slog.Debug("stop at HTTP boundary", slog.String("func", funcDecl.Name.Name))
 			return true, StopReasonHTTP, nil
@@ -75,6 +94,59 @@ func shouldStopAt(funcDecl *ast.FuncDecl, pkg *packages.Package, opts Options, s
 	return false, StopReasonNone, nil
 }
 
+// boundaryFuncLit returns the innermost function literal of enc around call that is a boundary by
+// itself, wrapped in a FuncDecl named like enc so that the boundary helpers apply to it, and its
+// reason. It returns nil when there is none.
+func boundaryFuncLit(enc *ast.FuncDecl, call *ast.CallExpr, pkg *packages.Package, opts Options) (*ast.FuncDecl, StopReason) {
+	if enc.Body == nil {
+		return nil, StopReasonNone
+	}
+	var lits []*ast.FuncLit
+	ast.Inspect(enc.Body, func(n ast.Node) bool {
+		if n == nil || n.Pos() > call.Pos() || n.End() < call.End() {
+			return false
+		}
+		if lit, ok := n.(*ast.FuncLit); ok {
+			lits = append(lits, lit)
+		}
+
+		return true
+	})
+	for i := len(lits) - 1; i >= 0; i-- {
+		decl := &ast.FuncDecl{Name: enc.Name, Type: lits[i].Type, Body: lits[i].Body}
+		if reason, ok := boundaryRuleReason(decl, pkg, opts); ok {
+			return decl, reason
+		}
+		if opts.httpHandlers() && isHTTPHandlerFunc(decl, pkg) {
+			return decl, StopReasonHTTP
+		}
+	}
+
+	return nil, StopReasonNone
+}
+
+// isPublicAPI reports whether fn is part of a library package's public API: an exported function,
+// or an exported method of an exported type, declared in a non-test file of a package that is
+// neither main nor internal.
+func isPublicAPI(fn *ast.FuncDecl, pkg *packages.Package) bool {
+	if !fn.Name.IsExported() || pkg.Name == FuncNameMain {
+		return false
+	}
+	if fn.Recv != nil && !ast.IsExported(recvBaseName(fn)) {
+		return false
+	}
+	for _, elem := range strings.Split(pkg.PkgPath, "/") {
+		if elem == "internal" {
+			return false
+		}
+	}
+	if file := pkg.Fset.File(fn.Pos()); file == nil || strings.HasSuffix(file.Name(), "_test.go") {
+		return false
+	}
+
+	return true
+}
+
 func isMainFunction(fn *ast.FuncDecl, pkg *packages.Package) bool {
 	if fn == nil || fn.Recv != nil {
 		return false
@@ -176,44 +248,85 @@ func isHTTPHandlerFunc(fn *ast.FuncDecl, pkg *packages.Package) bool {
 }
 
 // ensureCtxAvailableAtBoundary ensures that inside fn, a ctx variable exists.
-// If reason is StopReasonMain: inserts ctx := context.Background() at top if not present.
+// If reason is StopReasonMain: inserts ctx := context.Background() at top if not present, or the root
+// context chosen by opts.MainCtx in main.
 // If reason is OptNameHTTP: inserts ctx := <req>.Context() where <req> is the name of the *http.Request parameter.
-func ensureCtxAvailableAtBoundary(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl, reason StopReason) (bool, error) {
-	if hasCtxInScope(fn, pkg) {
+// If reason is StopReasonRule or StopReasonCLI, or StopReasonHTTP for a web framework handler: inserts
+// ctx := <expr> rendered by the first of rules matching a parameter.
+// When cp is narrower than context.Context, only the rules of opts.BoundaryRules apply; other
+// boundaries are an error.
+func ensureCtxAvailableAtBoundary(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl, reason StopReason, opts Options, cp ctxParam) (bool, error) {
+	rules := opts.boundaryRules()
+	if hasCtxInScope(fn, pkg, cp) {
 		slog.Debug("ctx already in scope at boundary", slog.String("func", fn.Name.Name))
 		return true, nil
 	}
+	if cp.generic {
+		return insertParamRoot(fn, reason, cp)
+	}
+	if cp.narrow && reason != StopReasonStopAt {
+		// main, tests, handlers and the built-in rules derive a context.Context; only the user's
+		// rules can derive the configured type.
+		if rule, _ := matchBoundaryRule(fn, pkg, opts.BoundaryRules); rule != nil {
+			return insertCtxFromRule(pkg, file, fn, opts.BoundaryRules, cp.name)
+		}
+
+		return false, fmt.Errorf("propagation reached %s, a %s boundary, which derives a context.Context, not a %s; add a boundary rule deriving %s from a parameter of %s", fn.Name.Name, reason, cp.typeText(""), cp.name, fn.Name.Name)
+	}
 
 	switch reason { //nolint:exhaustive // False positive; this switch has a `default` clause.
 	case StopReasonMain:
-		ensureImport(pkg.Fset, file, "context")
-		stmt := makeAssignCtxBackground()
-		insertAfterLeadingBlankAssignsF(pkg.Fset, file, fn, stmt)
-		slog.Debug("inserted ctx := context.Background()", slog.String("func", fn.Name.Name))
+		// The root context policy applies to main; TestMain keeps context.Background().
+		policy := mainCtxPolicy{kind: MainCtxBackground}
+		if isMainFunction(fn, pkg) {
+			var err error
+			if policy, err = parseMainCtx(opts.MainCtx); err != nil {
+				return false, err
+			}
+		}
+		names := newMainNames(pkg, file, fn)
+		stmts := policy.stmts(cp.name, names)
+		names.addImports(pkg.Fset, file)
+		insertAfterLeadingBlankAssignsF(pkg.Fset, file, fn, stmts[0])
+		insertAfterStmtF(fn, stmts[0], stmts[1:]...)
+		slog.Debug("inserted root ctx", slog.String("func", fn.Name.Name), slog.String("policy", policy.kind))
 
 		return true, nil
 	case StopReasonHTTP:
+		if rule, _ := matchBoundaryRule(fn, pkg, rules); rule != nil {
+			// A handler of a web framework (gin, echo, fiber).
+			return insertCtxFromRule(pkg, file, fn, rules, cp.name)
+		}
 		reqName := findHTTPRequestParamName(fn, pkg)
 		if reqName == "" {
 			return false, errors.New("determining http request parameter name")
 		}
-		stmt := makeAssignCtxFromRequest(reqName)
+		stmt := makeAssignCtxFromRequest(cp.name, reqName)
 		insertAtFuncStartF(fn, stmt)
 		slog.Debug("inserted ctx := req.Context()", slog.String("func", fn.Name.Name), slog.String("req", reqName))
 
 		return true, nil
+	case StopReasonRule, StopReasonCLI:
+		if isCLIV3Action(fn, pkg) {
+			nameCLIV3CtxParam(fn, cp.name)
+			slog.Debug("named context parameter of urfave/cli v3 action", slog.String("func", fn.Name.Name))
+
+			return true, nil
+		}
+
+		return insertCtxFromRule(pkg, file, fn, rules, cp.name)
 	case StopReasonTest:
 		testName := findTestingParamName(fn, pkg)
 		if testName == "" {
 			// Fall back to background if we cannot determine a testing param name (should be rare)
 			ensureImport(pkg.Fset, file, "context")
-			stmt := makeAssignCtxBackground()
+			stmt := makeAssignCtxBackground(cp.name)
 			insertAtFuncStartF(fn, stmt)
 			slog.Debug("inserted ctx := context.Background() (fallback for testing boundary)", slog.String("func", fn.Name.Name))
 
 			return true, nil
 		}
-		stmt := makeAssignCtxFromTesting(testName)
+		stmt := makeAssignCtxFromTesting(cp.name, testName)
 		// For testing boundaries, ensure ctx is initialized BEFORE any statements (including
 		// leading blank assigns like `_ = HelperTarget(...)`) so that those calls can use ctx.
 		insertAtFuncStartF(fn, stmt)
@@ -225,6 +338,40 @@ func ensureCtxAvailableAtBoundary(pkg *packages.Package, file *ast.File, fn *ast
 	}
 }
 
+// insertParamRoot inserts name := root at the start of a boundary when threading a ParamSpec.
+// Without a root expression, stop-at functions are left to the user, as with contexts.
+func insertParamRoot(fn *ast.FuncDecl, reason StopReason, cp ctxParam) (bool, error) {
+	if cp.root == "" {
+		if reason == StopReasonStopAt {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("propagation of %s reached %s, a %s boundary; give a root expression to create %s there", cp.name, fn.Name.Name, reason, cp.name)
+	}
+	insertAtFuncStartF(fn, &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(cp.name)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{ast.NewIdent(cp.root)}, // printed verbatim, like rule expressions
+	})
+	slog.Debug("inserted param root", slog.String("func", fn.Name.Name), slog.String("root", cp.root))
+
+	return true, nil
+}
+
+// insertCtxFromRule inserts <name> := <expr> at the start of fn, rendered by the first of rules
+// matching a parameter of fn.
+func insertCtxFromRule(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl, rules []BoundaryRule, name string) (bool, error) {
+	stmt, expr, err := makeAssignCtxFromRule(fn, pkg, rules, name)
+	if err != nil {
+		return false, err
+	}
+	ensureContextImportFor(pkg.Fset, file, expr)
+	insertAtFuncStartF(fn, stmt)
+	slog.Debug("inserted ctx from boundary rule", slog.String("func", fn.Name.Name), slog.String("expr", expr))
+
+	return true, nil
+}
+
 // insertAfterLeadingBlankAssignsF inserts a statement after leading blank assigns.
 // Adjusts formatting and positions to maintain proper syntax and style.
 // Handles positioning relative to comments or existing statements in the function.
@@ -283,6 +430,20 @@ func insertAfterLeadingBlankAssignsF(fset *token.FileSet, file *ast.File, fn *as
 	fn.Body.List = append(fn.Body.List[:idx], append([]ast.Stmt{stmt}, fn.Body.List[idx:]...)...)
 }
 
+// insertAfterStmtF inserts stmts right after anchor in fn.Body.
+func insertAfterStmtF(fn *ast.FuncDecl, anchor ast.Stmt, stmts ...ast.Stmt) {
+	if fn == nil || fn.Body == nil || len(stmts) == 0 {
+		return
+	}
+	for i, s := range fn.Body.List {
+		if s == anchor {
+			fn.Body.List = append(fn.Body.List[:i+1], append(stmts, fn.Body.List[i+1:]...)...)
+
+			return
+		}
+	}
+}
+
 // insertAtFuncStartF inserts stmt as the first statement of fn.Body, adjusting
 // token positions so formatting is stable and existing comments remain attached
 // to their intended lines.
@@ -324,10 +485,10 @@ func setAssignApproxPos(assign *ast.AssignStmt, base token.Pos) {
 	}
 }
 
-func makeAssignCtxBackground() ast.Stmt {
+func makeAssignCtxBackground(name string) ast.Stmt {
 	// ctx := context.Background()
 	assign := &ast.AssignStmt{
-		Lhs: []ast.Expr{ast.NewIdent(VarNameCtx)},
+		Lhs: []ast.Expr{ast.NewIdent(name)},
 		Tok: token.DEFINE,
 		Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("Background")}}},
 	}
@@ -335,19 +496,19 @@ func makeAssignCtxBackground() ast.Stmt {
 	return assign
 }
 
-func makeAssignCtxFromRequest(req string) ast.Stmt {
+func makeAssignCtxFromRequest(name, req string) ast.Stmt {
 	// ctx := <req>.Context()
 	return &ast.AssignStmt{
-		Lhs: []ast.Expr{ast.NewIdent(VarNameCtx)},
+		Lhs: []ast.Expr{ast.NewIdent(name)},
 		Tok: token.DEFINE,
 		Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(req), Sel: ast.NewIdent("Context")}}},
 	}
 }
 
-func makeAssignCtxFromTesting(tvar string) ast.Stmt {
+func makeAssignCtxFromTesting(name, tvar string) ast.Stmt {
 	// ctx := <tvar>.Context()
 	return &ast.AssignStmt{
-		Lhs: []ast.Expr{ast.NewIdent(VarNameCtx)},
+		Lhs: []ast.Expr{ast.NewIdent(name)},
 		Tok: token.DEFINE,
 		Rhs: []ast.Expr{&ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(tvar), Sel: ast.NewIdent("Context")}}},
 	}
@@ -379,9 +540,9 @@ func findHTTPRequestParamName(fn *ast.FuncDecl, p *packages.Package) string {
 	return ""
 }
 
-func ensureCallHasCtxArg(pkg *packages.Package, call *ast.CallExpr, ctxName string) {
+func ensureCallHasCtxArg(pkg *packages.Package, call *ast.CallExpr, ctxName string, cp ctxParam) {
 	if ctxName == "" {
-		ctxName = VarNameCtx
+		ctxName = cp.name
 	}
 	// If there's already a first argument and it's either the same identifier name
 	// or it is of type context.Context, avoid adding a duplicate.
@@ -392,13 +553,9 @@ func ensureCallHasCtxArg(pkg *packages.Package, call *ast.CallExpr, ctxName stri
 				return
 			}
 		}
-		// Case 2: first arg type is context.Context
-		if pkg != nil && pkg.TypesInfo != nil {
-			if t := pkg.TypesInfo.TypeOf(call.Args[0]); t != nil {
-				if types.TypeString(t, func(p *types.Package) string { return p.Path() }) == ContextContext {
-					return
-				}
-			}
+		// Case 2: first arg type is a context type
+		if pkg != nil && pkg.TypesInfo != nil && cp.isContext(pkg.TypesInfo.TypeOf(call.Args[0])) {
+			return
 		}
 	}
 
@@ -406,25 +563,26 @@ func ensureCallHasCtxArg(pkg *packages.Package, call *ast.CallExpr, ctxName stri
 	call.Args = append([]ast.Expr{ast.NewIdent(ctxName)}, call.Args...)
 }
 
-func getCtxIdentInScope(fn *ast.FuncDecl, pkg *packages.Package) string {
+// getCtxIdentInScope returns the name of a context in scope in fn, or "". Values of the types cp
+// accepts count (see ctxParam.accepts).
+func getCtxIdentInScope(fn *ast.FuncDecl, pkg *packages.Package, cp ctxParam) string {
 	// 1) Prefer a parameter literally named "ctx" (regardless of type info availability)
 	if fn != nil && fn.Type != nil && fn.Type.Params != nil {
 		for _, field := range fn.Type.Params.List {
 			for _, name := range field.Names {
-				if name != nil && name.Name == VarNameCtx {
-					return VarNameCtx
+				if name != nil && name.Name == cp.name && !rejectsIdent(pkg, name, cp) {
+					return cp.name
 				}
 			}
 		}
 	}
-	// 2) Prefer any parameter of type context.Context with a usable name (not underscore)
+	// 2) Prefer any parameter of a context type with a usable name (not underscore)
 	if fn != nil && fn.Type != nil && fn.Type.Params != nil {
 		for _, field := range fn.Type.Params.List {
 			if field == nil || field.Type == nil {
 				continue
 			}
-			t := pkg.TypesInfo.TypeOf(field.Type)
-			if types.TypeString(t, func(p *types.Package) string { return p.Path() }) != ContextContext {
+			if !cp.accepts(pkg.TypesInfo.TypeOf(field.Type)) {
 				continue
 			}
 			if len(field.Names) > 0 {
@@ -439,8 +597,8 @@ func getCtxIdentInScope(fn *ast.FuncDecl, pkg *packages.Package) string {
 	var found string
 	ast.Inspect(fn, func(n ast.Node) bool {
 		if id, ok := n.(*ast.Ident); ok {
-			if id.Name == VarNameCtx {
-				found = VarNameCtx
+			if id.Name == cp.name && !rejectsIdent(pkg, id, cp) {
+				found = cp.name
 				return false
 			}
 		}
@@ -450,7 +608,7 @@ func getCtxIdentInScope(fn *ast.FuncDecl, pkg *packages.Package) string {
 	if found != "" {
 		return found
 	}
-	// 4) Finally, search identifiers with type context.Context via types info (best-effort when info is present)
+	// 4) Finally, search identifiers of a context type via types info (best-effort when info is present)
 	ast.Inspect(fn, func(n ast.Node) bool {
 		id, ok := n.(*ast.Ident)
 		if !ok || id.Name == "_" || id.Name == "" {
@@ -465,7 +623,7 @@ func getCtxIdentInScope(fn *ast.FuncDecl, pkg *packages.Package) string {
 		}
 		// Only consider value identifiers (variables/params), ignore type names like the 'Context' in 'context.Context'.
 		if v, ok := obj.(*types.Var); ok {
-			if types.TypeString(v.Type(), func(p *types.Package) string { return p.Path() }) == ContextContext {
+			if cp.accepts(v.Type()) {
 				found = id.Name
 				return false
 			}
@@ -477,8 +635,92 @@ func getCtxIdentInScope(fn *ast.FuncDecl, pkg *packages.Package) string {
 	return found
 }
 
-func hasCtxInScope(fn *ast.FuncDecl, pkg *packages.Package) bool {
-	return getCtxIdentInScope(fn, pkg) != ""
+// rejectsIdent reports whether the type information shows id is a variable cp does not accept,
+// e.g. a context.Context where a narrower type is configured. Identifiers goctx added are unknown.
+func rejectsIdent(pkg *packages.Package, id *ast.Ident, cp ctxParam) bool {
+	if pkg == nil || pkg.TypesInfo == nil || !cp.narrow {
+		return false
+	}
+	obj := pkg.TypesInfo.Defs[id]
+	if obj == nil {
+		obj = pkg.TypesInfo.Uses[id]
+	}
+	v, ok := obj.(*types.Var)
+
+	return ok && !cp.accepts(v.Type())
+}
+
+// ctxIdentAt returns the name of a context in scope at pos in fn, or "": a variable of fn declared
+// before pos in an enclosing block, or a context goctx gave fn as a parameter or at its start,
+// which the type information does not know about.
+func ctxIdentAt(fn *ast.FuncDecl, pkg *packages.Package, cp ctxParam, pos token.Pos) string {
+	var scope *types.Scope
+	if pos.IsValid() && pkg.Types != nil {
+		scope = pkg.Types.Scope().Innermost(pos)
+	}
+	inFn := func(obj types.Object) bool {
+		return obj != nil && obj.Pos() >= fn.Pos() && obj.Pos() < fn.End()
+	}
+	if scope != nil {
+		names := []string{cp.name}
+		for s := scope; s != nil && s != pkg.Types.Scope(); s = s.Parent() {
+			names = append(names, s.Names()...)
+		}
+		for _, name := range names {
+			_, obj := scope.LookupParent(name, pos)
+			if v, ok := obj.(*types.Var); ok && name != "_" && inFn(v) && cp.accepts(v.Type()) {
+				return name
+			}
+		}
+	}
+	if !ctxAddedBefore(fn, pkg, cp.name, pos) {
+		return ""
+	}
+	if scope != nil {
+		// A local of fn with the same name shadows the added context.
+		if _, obj := scope.LookupParent(cp.name, pos); inFn(obj) {
+			return ""
+		}
+	}
+
+	return cp.name
+}
+
+// ctxAddedBefore reports whether goctx added name to fn as a parameter, or declared it in a
+// statement of fn.Body that precedes pos.
+func ctxAddedBefore(fn *ast.FuncDecl, pkg *packages.Package, name string, pos token.Pos) bool {
+	added := func(id *ast.Ident) bool {
+		return id.Name == name && pkg.TypesInfo.Defs[id] == nil
+	}
+	if fn.Type.Params != nil {
+		for _, field := range fn.Type.Params.List {
+			if slices.ContainsFunc(field.Names, added) {
+				return true
+			}
+		}
+	}
+	if fn.Body == nil {
+		return false
+	}
+	for _, stmt := range fn.Body.List {
+		if as, ok := stmt.(*ast.AssignStmt); ok && as.Tok == token.DEFINE {
+			for _, lhs := range as.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && added(id) {
+					return true
+				}
+			}
+		}
+		if pos.IsValid() && stmt.Pos() <= pos && pos < stmt.End() {
+			// pos is in a statement of the original code before any added declaration.
+			return false
+		}
+	}
+
+	return false
+}
+
+func hasCtxInScope(fn *ast.FuncDecl, pkg *packages.Package, cp ctxParam) bool {
+	return getCtxIdentInScope(fn, pkg, cp) != ""
 }
 
 // isTestingBoundary reports whether the function has a parameter of type testing.T, testing.B,
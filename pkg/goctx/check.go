@@ -0,0 +1,461 @@
+package goctx
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// CheckRule identifies the kind of broken context chain reported by Check.
+type CheckRule string
+
+const (
+	// CheckRuleCalleeCreatesContext: a function with a ctx in scope calls an in-module function that
+	// creates its own root context via context.Background() or context.TODO().
+	CheckRuleCalleeCreatesContext CheckRule = "callee-creates-context"
+	// CheckRuleRootContextArg: a context-accepting callee is passed context.Background() or
+	// context.TODO() although a ctx is in scope.
+	CheckRuleRootContextArg CheckRule = "root-context-arg"
+	// CheckRuleUnusedCtxParam: a function's context parameter is unused (or blank) even though
+	// one of its callees needs a context.
+	CheckRuleUnusedCtxParam CheckRule = "unused-ctx-param"
+)
+
+// Diagnostic describes a single broken context chain found by Check.
+// Start and End delimit the offending call within the package's FileSet.
+type Diagnostic struct {
+	Pos      token.Position
+	Start    token.Pos
+	End      token.Pos
+	Rule     CheckRule
+	Function string // name of the enclosing function
+	Callee   string // fully qualified name of the offending callee
+	Message  string
+	Fixes    []Fix
+}
+
+// Fix is a suggested, self-contained set of edits that repairs a Diagnostic.
+type Fix struct {
+	Message string
+	Edits   []TextEdit
+}
+
+// TextEdit replaces the source between Pos and End with NewText.
+type TextEdit struct {
+	Pos     token.Pos
+	End     token.Pos
+	NewText string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Rule)
+}
+
+// Check loads the module containing opts.WorkDir and reports broken context chains in the
+// packages matching patterns (all packages of the module when patterns is empty). It does not
+// modify any file. Boundaries (tests, --http handlers, --stop-at functions) are evaluated with
+// the same rules the rewriter uses.
+func Check(ctx context.Context, opts Options, patterns []string) ([]Diagnostic, error) {
+	workDir := firstNonEmpty(opts.WorkDir, ".")
+	slog.Debug("check start", slog.String("workDir", workDir), slog.Any("patterns", patterns))
+
+	if err := validateBoundaryRules(opts.BoundaryRules); err != nil {
+		return nil, err
+	}
+	if err := validateWeb(opts.Web); err != nil {
+		return nil, err
+	}
+	if err := validateContextSources(opts.ContextSources); err != nil {
+		return nil, err
+	}
+	filter, err := newFileFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadAllPackages(ctx, workDir, opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	stops, err := parseStopSpecs(opts)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := resolveCtxParam(opts, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectPackages(ctx, workDir, opts.Tags, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	createsCtx := indexContextCreators(pkgs, cp)
+
+	var diags []Diagnostic
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if selected != nil && !selected[pkg.PkgPath] {
+			continue
+		}
+		if err := interrupted(ctx, "checking packages"); err != nil {
+			return nil, err
+		}
+		pkgDiags, err := checkPackage(pkg, opts, cp, stops, createsCtx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range pkgDiags {
+			// Test variants of a package share most files; report each finding once.
+			key := d.String()
+			if seen[key] || !filter.allows(d.Pos.Filename) {
+				continue
+			}
+			seen[key] = true
+			diags = append(diags, d)
+		}
+	}
+	sortDiagnostics(diags)
+	slog.Debug("check done", slog.Int("diagnostics", len(diags)))
+
+	return diags, nil
+}
+
+// selectPackages resolves patterns to a set of package paths. A nil map means "all packages".
+func selectPackages(ctx context.Context, dir string, tags string, patterns []string) (map[string]bool, error) {
+	if len(patterns) == 0 {
+		return nil, nil //nolint:nilnil // A nil set means no filtering.
+	}
+	cfg := &packages.Config{Context: ctx, Mode: packages.NeedName, Dir: dir, Tests: true}
+	if tags != "" {
+		cfg.BuildFlags = []string{"-tags=" + tags}
+	}
+	matched, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("resolving package patterns: %w", err)
+	}
+	selected := make(map[string]bool, len(matched))
+	for _, p := range matched {
+		selected[p.PkgPath] = true
+	}
+
+	return selected, nil
+}
+
+// CheckPackage applies the check rules to a single type-checked package, as Check does for
+// every selected package. createsCtx reports whether a callee creates its own root context;
+// callers outside a whole-module load (e.g. go/analysis drivers) supply it from their own index.
+func CheckPackage(pkg *packages.Package, opts Options, createsCtx func(types.Object) bool) ([]Diagnostic, error) {
+	if err := validateWeb(opts.Web); err != nil {
+		return nil, err
+	}
+	stops, err := parseStopSpecs(opts)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := resolveCtxParam(opts, []*packages.Package{pkg})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkPackage(pkg, opts, cp, stops, createsCtx)
+}
+
+// CreatesOwnContext reports whether fn manufactures a root context (context.Background() or
+// context.TODO()) instead of accepting one. Entry points such as main and TestMain never do.
+func CreatesOwnContext(fn *ast.FuncDecl, pkg *packages.Package) bool {
+	return createsOwnContext(fn, pkg, defaultCtxParam)
+}
+
+// indexContextCreators returns a predicate reporting whether a function declared in pkgs creates
+// its own root context (calls context.Background() or context.TODO()) without accepting one.
+func indexContextCreators(pkgs []*packages.Package, cp ctxParam) func(types.Object) bool {
+	creators := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				if createsOwnContext(fn, pkg, cp) {
+					if obj := pkg.TypesInfo.Defs[fn.Name]; obj != nil {
+						creators[funcKey(obj)] = true
+					}
+				}
+			}
+		}
+	}
+
+	return func(obj types.Object) bool {
+		return obj != nil && creators[funcKey(obj)]
+	}
+}
+
+// createsOwnContext reports whether fn manufactures a root context instead of accepting one.
+// Entry points (main, TestMain) are expected to do so and are never reported.
+func createsOwnContext(fn *ast.FuncDecl, pkg *packages.Package, cp ctxParam) bool {
+	if fn == nil || fn.Body == nil {
+		return false
+	}
+	if isMainFunction(fn, pkg) || isTestMainFunction(fn, pkg) {
+		return false
+	}
+	if functionHasContextParam(fn, pkg.TypesInfo, cp) {
+		return false
+	}
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && isRootContextCall(pkg.TypesInfo, call) {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// isRootContextCall reports whether expr is a call to context.Background() or context.TODO().
+func isRootContextCall(info *types.Info, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "Background" && sel.Sel.Name != "TODO") {
+		return false
+	}
+	if info != nil {
+		if obj := info.Uses[sel.Sel]; obj != nil {
+			return obj.Pkg() != nil && obj.Pkg().Path() == "context"
+		}
+	}
+	xid, ok := sel.X.(*ast.Ident)
+
+	return ok && xid.Name == "context"
+}
+
+// funcKey returns a stable identity for a function or method that survives test variants.
+func funcKey(obj types.Object) string {
+	if fn, ok := obj.(*types.Func); ok {
+		return fn.FullName()
+	}
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// funcKeyOfDecl is funcKey for a declaration, falling back to its bare name when untyped.
+func funcKeyOfDecl(pkg *packages.Package, fn *ast.FuncDecl) string {
+	if obj := pkg.TypesInfo.Defs[fn.Name]; obj != nil {
+		return funcKey(obj)
+	}
+
+	return fn.Name.Name
+}
+
+// checkPackage applies the check rules to every function declared in pkg.
+func checkPackage(pkg *packages.Package, opts Options, cp ctxParam, stops *stopSet, createsCtx func(types.Object) bool) ([]Diagnostic, error) {
+	var diags []Diagnostic
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			fnDiags, err := checkFunc(pkg, fn, opts, cp, stops, createsCtx)
+			if err != nil {
+				return nil, err
+			}
+			diags = append(diags, fnDiags...)
+		}
+	}
+
+	return diags, nil
+}
+
+// checkFunc reports broken context chains inside a single function declaration.
+func checkFunc(pkg *packages.Package, fn *ast.FuncDecl, opts Options, cp ctxParam, stops *stopSet, createsCtx func(types.Object) bool) ([]Diagnostic, error) {
+	unusedIdent := unusedCtxParam(fn, pkg.TypesInfo, cp)
+	unusedParam := ""
+	if unusedIdent != nil {
+		unusedParam = unusedIdent.Name
+	}
+	fnCtx := getCtxIdentInScope(fn, pkg, cp)
+	sourceCtx := ""
+	if fnCtx == "" {
+		var err error
+		if sourceCtx, err = ctxSourceInScope(fn, pkg, opts.ContextSources); err != nil {
+			return nil, fmt.Errorf("deriving ctx in %s: %w", fn.Name.Name, err)
+		}
+	}
+	if fnCtx == "" && sourceCtx == "" && unusedParam == "" {
+		// No ctx in scope: only boundaries where the rewriter would derive one count as "in scope".
+		stopHere, reason, err := shouldStopAt(fn, pkg, opts, stops)
+		if err != nil {
+			return nil, fmt.Errorf("checking stop boundary: %w", err)
+		}
+		if !stopHere || (reason != StopReasonHTTP && reason != StopReasonTest && reason != StopReasonRule && reason != StopReasonCLI) {
+			return nil, nil
+		}
+	}
+
+	// main and TestMain derive the root context of the program from a root context.
+	entry := isMainFunction(fn, pkg) || isTestMainFunction(fn, pkg)
+	var diags []Diagnostic
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		calledObj, _ := resolveCalled(pkg.TypesInfo, call.Fun)
+		calleeName := ""
+		if calledObj != nil {
+			calleeName = funcKey(calledObj)
+		}
+		ctxName := sourceCtx
+		if fnCtx != "" {
+			// Only a ctx declared before the call is in scope there, e.g. not the one that
+			// "ctx, cancel := context.WithTimeout(context.Background(), d)" declares.
+			ctxName = ctxIdentAt(fn, pkg, cp, call.Pos())
+			if ctxName == "" && unusedParam == "" {
+				return true
+			}
+		}
+		pos := pkg.Fset.Position(call.Lparen)
+
+		needsCtx := false
+		var rule CheckRule
+		var msg string
+		switch {
+		case calledObj != nil && createsCtx(calledObj):
+			needsCtx = true
+			rule = CheckRuleCalleeCreatesContext
+			msg = fmt.Sprintf("%s calls %s, which creates its own root context; pass ctx instead", fn.Name.Name, calleeName)
+		case hasRootContextArg(pkg.TypesInfo, call) && !(entry && derivesRootContext(calledObj)):
+			needsCtx = true
+			rule = CheckRuleRootContextArg
+			msg = fmt.Sprintf("%s passes a root context to %s although a ctx is in scope", fn.Name.Name, calleeNameOrExpr(calleeName, call))
+		}
+		if !needsCtx {
+			return true
+		}
+		if unusedParam != "" {
+			rule = CheckRuleUnusedCtxParam
+			msg = fmt.Sprintf("context parameter %q of %s is unused, but callee %s needs a context", unusedParam, fn.Name.Name, calleeNameOrExpr(calleeName, call))
+		}
+		diag := Diagnostic{
+			Pos: pos, Start: call.Pos(), End: call.End(),
+			Rule: rule, Function: fn.Name.Name, Callee: calleeName, Message: msg,
+		}
+		diag.Fixes = suggestFixes(pkg, call, calledObj, ctxName, cp, unusedIdent)
+		diags = append(diags, diag)
+
+		return true
+	})
+
+	return diags, nil
+}
+
+func calleeNameOrExpr(name string, call *ast.CallExpr) string {
+	if name != "" {
+		return name
+	}
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	default:
+		return "callee"
+	}
+}
+
+// hasRootContextArg reports whether any context.Context argument of call is context.Background()
+// or context.TODO().
+func hasRootContextArg(info *types.Info, call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		if isRootContextCall(info, arg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// derivesRootContext reports whether obj is a context.With* function or signal.NotifyContext.
+func derivesRootContext(obj types.Object) bool {
+	fn, ok := obj.(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Signature().Recv() != nil {
+		return false
+	}
+	switch fn.Pkg().Path() {
+	case "context":
+		return strings.HasPrefix(fn.Name(), "With")
+	case "os/signal":
+		return fn.Name() == "NotifyContext"
+	}
+
+	return false
+}
+
+// unusedCtxParam returns the identifier of fn's context parameter when that parameter is
+// blank or never referenced in the body. It returns nil when fn has no such parameter or uses it.
+func unusedCtxParam(fn *ast.FuncDecl, info *types.Info, cp ctxParam) *ast.Ident {
+	if fn == nil || fn.Type == nil || fn.Type.Params == nil || fn.Body == nil {
+		return nil
+	}
+	for _, field := range fn.Type.Params.List {
+		if field == nil || !isContextType(info, field.Type, cp) {
+			continue
+		}
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				return name
+			}
+			if info == nil {
+				continue
+			}
+			param := info.Defs[name]
+			if param == nil {
+				continue
+			}
+			used := false
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && info.Uses[id] == param {
+					used = true
+				}
+
+				return !used
+			})
+			if !used {
+				return name
+			}
+		}
+	}
+
+	return nil
+}
+
+func sortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Pos, diags[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+}
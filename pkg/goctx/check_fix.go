@@ -0,0 +1,285 @@
+package goctx
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// suggestFixes computes the edits goctx would make for a single offending call. ctxName is the
+// context identifier in scope at the call (possibly ""), and unused is the enclosing function's
+// unused context parameter, if any. cp names and types the context parameters the fix introduces.
+// It returns nil when no safe, package-local fix exists.
+func suggestFixes(pkg *packages.Package, call *ast.CallExpr, calledObj types.Object, ctxName string, cp ctxParam, unused *ast.Ident) []Fix {
+	newName := cp.name
+	var edits []TextEdit
+	if unused != nil {
+		// Put the unused parameter to work: rename '_' to newName, otherwise reuse its name.
+		ctxName = unused.Name
+		if ctxName == "_" {
+			ctxName = newName
+			edits = append(edits, TextEdit{Pos: unused.Pos(), End: unused.End(), NewText: newName})
+		}
+	}
+	if ctxName == "" {
+		// Boundaries without a ctx yet (tests, handlers) need the rewriter to derive one first.
+		return nil
+	}
+
+	if hasRootContextArg(pkg.TypesInfo, call) {
+		for _, arg := range call.Args {
+			if isRootContextCall(pkg.TypesInfo, arg) {
+				edits = append(edits, TextEdit{Pos: arg.Pos(), End: arg.End(), NewText: ctxName})
+			}
+		}
+
+		return []Fix{{Message: "Pass " + ctxName + " instead of a root context", Edits: edits}}
+	}
+
+	calleeEdits := calleeAcceptsCtxEdits(pkg, call, calledObj, ctxName, cp)
+	if calleeEdits == nil {
+		return nil
+	}
+
+	return []Fix{{Message: "Add a context parameter to " + calledObj.Name() + " and pass " + ctxName, Edits: append(edits, calleeEdits...)}}
+}
+
+// calleeAcceptsCtxEdits turns a same-package callee that creates its own root context into one
+// accepting a context: its signature gains a leading parameter named and typed as cp, its root contexts
+// are replaced by that parameter, and every call site in the package passes a context (ctxName at call, the in-scope ctx or
+// context.TODO() elsewhere).
+func calleeAcceptsCtxEdits(pkg *packages.Package, call *ast.CallExpr, calledObj types.Object, ctxName string, cp ctxParam) []TextEdit {
+	newName := cp.name
+	callee := findFuncDeclByObj(pkg, calledObj)
+	if callee == nil || callee.Type.Params == nil || hasParamNamed(callee.Type.Params, newName) {
+		return nil
+	}
+	if cp.typ != nil && cp.path != pkg.PkgPath && !fileImports(fileOfNode(pkg, callee), cp.path) {
+		// The fix does not add imports.
+		return nil
+	}
+
+	var edits []TextEdit
+	paramText := newName + " " + cp.typeText(pkg.PkgPath)
+	if len(callee.Type.Params.List) > 0 {
+		paramText += ", "
+	}
+	edits = append(edits, TextEdit{Pos: callee.Type.Params.Opening + 1, End: callee.Type.Params.Opening + 1, NewText: paramText})
+	redeclEdits, ok := redeclarationEdits(pkg, callee, cp)
+	if !ok {
+		return nil
+	}
+	edits = append(edits, redeclEdits...)
+	edits = append(edits, replaceRootContextsEdits(pkg, callee, newName)...)
+
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			enc, ok := decl.(*ast.FuncDecl)
+			if !ok || enc.Body == nil {
+				continue
+			}
+			var siteErr bool
+			ast.Inspect(enc.Body, func(n ast.Node) bool {
+				site, ok := n.(*ast.CallExpr)
+				if !ok || siteErr || !matchCallTarget(pkg, site, calledObj) {
+					return !siteErr
+				}
+				arg := ctxName
+				switch {
+				case site == call:
+				case enc == callee:
+					arg = newName
+				default:
+					arg = getCtxIdentInScope(enc, pkg, cp)
+					if arg == "" {
+						if !fileImports(f, "context") {
+							siteErr = true
+							return false
+						}
+						arg = "context.TODO()"
+					}
+				}
+				edits = append(edits, insertLeadingArgEdit(site, arg))
+
+				return true
+			})
+			if siteErr {
+				return nil
+			}
+		}
+	}
+
+	return edits
+}
+
+// replaceRootContextsEdits replaces root context calls inside fn with name, deleting statements
+// that would otherwise degenerate into "ctx := ctx".
+func replaceRootContextsEdits(pkg *packages.Package, fn *ast.FuncDecl, name string) []TextEdit {
+	var edits []TextEdit
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt, *ast.DeclStmt:
+			if declaresRootContext(pkg, stmt.(ast.Stmt), name) {
+				edits = append(edits, deleteLineEdit(pkg.Fset, stmt))
+				return false
+			}
+		case *ast.CallExpr:
+			if isRootContextCall(pkg.TypesInfo, stmt) {
+				edits = append(edits, TextEdit{Pos: stmt.Pos(), End: stmt.End(), NewText: name})
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return edits
+}
+
+// declaresRootContext reports whether stmt is "name := <root context>" or "var name = <root
+// context>", which replaceRootContextsEdits deletes.
+func declaresRootContext(pkg *packages.Package, stmt ast.Stmt, name string) bool {
+	switch stmt := stmt.(type) {
+	case *ast.AssignStmt:
+		return len(stmt.Lhs) == 1 && len(stmt.Rhs) == 1 && isIdentNamed(stmt.Lhs[0], name) && isRootContextCall(pkg.TypesInfo, stmt.Rhs[0])
+	case *ast.DeclStmt:
+		if gd, ok := stmt.Decl.(*ast.GenDecl); ok && gd.Tok == token.VAR && len(gd.Specs) == 1 {
+			vs, ok := gd.Specs[0].(*ast.ValueSpec)
+
+			return ok && len(vs.Names) == 1 && len(vs.Values) == 1 && vs.Names[0].Name == name && isRootContextCall(pkg.TypesInfo, vs.Values[0])
+		}
+	}
+
+	return false
+}
+
+// redeclarationEdits turns the statements at the top level of fn's body that declare a variable
+// named like the new parameter, such as "ctx := context.WithValue(...)", into assignments to the
+// parameter, since they share its scope. It reports false when a declaration cannot become an
+// assignment: a var declaration, or a variable of a type the parameter does not accept.
+func redeclarationEdits(pkg *packages.Package, fn *ast.FuncDecl, cp ctxParam) ([]TextEdit, bool) {
+	var edits []TextEdit
+	for _, stmt := range fn.Body.List {
+		if declaresRootContext(pkg, stmt, cp.name) {
+			continue
+		}
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			if stmt.Tok != token.DEFINE {
+				continue
+			}
+			declared, others := false, false
+			for _, lhs := range stmt.Lhs {
+				id, ok := lhs.(*ast.Ident)
+				if !ok || pkg.TypesInfo.Defs[id] == nil {
+					continue
+				}
+				if id.Name != cp.name {
+					others = true
+					continue
+				}
+				if !cp.accepts(pkg.TypesInfo.Defs[id].Type()) {
+					return nil, false
+				}
+				declared = true
+			}
+			if declared && !others {
+				// With no other new variable on the left, := would not compile.
+				edits = append(edits, TextEdit{Pos: stmt.TokPos, End: stmt.TokPos + token.Pos(len(token.DEFINE.String())), NewText: token.ASSIGN.String()})
+			}
+		case *ast.DeclStmt:
+			if gd, ok := stmt.Decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+				for _, spec := range gd.Specs {
+					if vs, ok := spec.(*ast.ValueSpec); ok && slices.ContainsFunc(vs.Names, func(id *ast.Ident) bool { return id.Name == cp.name }) {
+						return nil, false
+					}
+				}
+			}
+		}
+	}
+
+	return edits, true
+}
+
+// insertLeadingArgEdit prepends arg to the argument list of call.
+func insertLeadingArgEdit(call *ast.CallExpr, arg string) TextEdit {
+	text := arg
+	if len(call.Args) > 0 {
+		text += ", "
+	}
+
+	return TextEdit{Pos: call.Lparen + 1, End: call.Lparen + 1, NewText: text}
+}
+
+// deleteLineEdit removes the whole line(s) spanned by node, including the trailing newline.
+func deleteLineEdit(fset *token.FileSet, node ast.Node) TextEdit {
+	tf := fset.File(node.Pos())
+	startLine := tf.Line(node.Pos())
+	endLine := tf.Line(node.End())
+	end := node.End()
+	if endLine < tf.LineCount() {
+		end = tf.LineStart(endLine + 1)
+	}
+
+	return TextEdit{Pos: tf.LineStart(startLine), End: end}
+}
+
+func findFuncDeclByObj(pkg *packages.Package, obj types.Object) *ast.FuncDecl {
+	if obj == nil {
+		return nil
+	}
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if ok && fn.Body != nil && pkg.TypesInfo.Defs[fn.Name] == obj {
+				return fn
+			}
+		}
+	}
+
+	return nil
+}
+
+// fileOfNode returns the file of pkg containing node, or nil.
+func fileOfNode(pkg *packages.Package, node ast.Node) *ast.File {
+	for _, f := range pkg.Syntax {
+		if f.FileStart <= node.Pos() && node.Pos() <= f.FileEnd {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func fileImports(file *ast.File, path string) bool {
+	for _, imp := range file.Imports {
+		if imp.Path != nil && strings.Trim(imp.Path.Value, "\"") == path && imp.Name == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIdentNamed(expr ast.Expr, name string) bool {
+	id, ok := expr.(*ast.Ident)
+
+	return ok && id.Name == name
+}
+
+// hasParamNamed reports whether any parameter is named name.
+func hasParamNamed(params *ast.FieldList, name string) bool {
+	for _, field := range params.List {
+		for _, nm := range field.Names {
+			if nm != nil && nm.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
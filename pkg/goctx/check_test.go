@@ -0,0 +1,64 @@
+package goctx
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// renderDiagnostics formats diagnostics with filenames relative to dir, one per line.
+func renderDiagnostics(t *testing.T, dir string, diags []Diagnostic) []byte {
+	t.Helper()
+
+	var sb strings.Builder
+	for _, d := range diags {
+		rel, err := filepath.Rel(dir, d.Pos.Filename)
+		require.NoError(t, err)
+		d.Pos.Filename = filepath.ToSlash(rel)
+		sb.WriteString(d.String())
+		sb.WriteString("\n")
+	}
+
+	return []byte(sb.String())
+}
+
+func TestE2E_Check_ReportsBrokenChains(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	before := readAllFiles(t, dir)
+	diags, err := Check(ctx, Options{WorkDir: dir}, nil)
+	require.NoError(t, err)
+	require.Equal(t, before, readAllFiles(t, dir), "check must not modify files")
+
+	g.Assert(t, "diagnostics.txt", renderDiagnostics(t, dir, diags))
+}
+
+func TestE2E_Check_CleanModule(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	dir := writeTempModuleFromInput(t)
+
+	diags, err := Check(ctx, Options{WorkDir: dir}, []string{"./..."})
+	require.NoError(t, err)
+	require.Empty(t, diags)
+}
+
+func TestE2E_Check_RootContextDeclarations(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	dir := writeTempModuleFromInput(t)
+
+	diags, err := Check(ctx, Options{WorkDir: dir}, []string{"./..."})
+	require.NoError(t, err)
+	require.Len(t, diags, 1, "only the root context passed to load once ctx is declared")
+	require.Equal(t, CheckRuleRootContextArg, diags[0].Rule)
+	require.Equal(t, "example.com/e2e.load", diags[0].Callee)
+}
@@ -0,0 +1,245 @@
+package goctx
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SuffixContext is appended to the name of an exported function to form its context-aware
+// sibling, following the database/sql Query/QueryContext convention.
+const SuffixContext = "Context"
+
+// compatWrapper records an exported function renamed to its context-aware sibling; the original
+// name is re-added as a deprecated wrapper once the file has been formatted.
+type compatWrapper struct {
+	Recv    string // receiver base type name; empty for functions
+	Name    string // original name, kept by the wrapper
+	NewName string // name of the context-aware sibling
+}
+
+// wantsCompatWrapper reports whether fn should get a context-aware sibling instead of a new
+// context parameter.
+func wantsCompatWrapper(opts Options, fn *ast.FuncDecl) bool {
+	return opts.CompatWrappers && fn.Name.IsExported() && fn.Body != nil
+}
+
+// convertToCompat renames the exported fn to its FooContext sibling, keeping its body, and records
+// the deprecated Foo wrapper to generate. The context parameter itself is added by the caller.
+func convertToCompat(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl, changes *changeLog) error {
+	obj := pkg.TypesInfo.Defs[fn.Name]
+	if obj == nil {
+		return fmt.Errorf("resolving function object for %s", fn.Name.Name)
+	}
+	name := fn.Name.Name
+	newName := name + SuffixContext
+	if compatNameTaken(pkg, obj, newName) {
+		return fmt.Errorf("cannot generate %s for %s: the name is already declared", newName, funcKey(obj))
+	}
+
+	fn.Name.Name = newName
+	// Keep godoc's "Foo does X" convention on the renamed function.
+	if fn.Doc != nil && len(fn.Doc.List) > 0 {
+		first := fn.Doc.List[0]
+		if rest, ok := strings.CutPrefix(first.Text, "// "+name+" "); ok {
+			first.Text = "// " + newName + " " + rest
+		}
+	}
+	changes.markCompat(obj, pkg.Fset.File(file.Pos()).Name(), compatWrapper{Recv: recvBaseName(fn), Name: name, NewName: newName})
+	changes.note(fn, "rename to %s and keep %s as a deprecated wrapper", newName, name)
+
+	return nil
+}
+
+// compatNameTaken reports whether newName is already declared next to obj: in the package scope
+// for functions, in the receiver's method set for methods.
+func compatNameTaken(pkg *packages.Package, obj types.Object, newName string) bool {
+	sig, ok := obj.Type().(*types.Signature)
+	if ok && sig.Recv() != nil {
+		found, _, _ := types.LookupFieldOrMethod(sig.Recv().Type(), true, pkg.Types, newName)
+
+		return found != nil
+	}
+
+	return pkg.Types.Scope().Lookup(newName) != nil
+}
+
+// redirectCallToCompat makes call invoke the context-aware sibling of name instead of the wrapper.
+// It is idempotent, as files shared with test variants are visited more than once.
+func redirectCallToCompat(call *ast.CallExpr, name string) {
+	fun := call.Fun
+	for {
+		switch x := fun.(type) {
+		case *ast.IndexExpr:
+			fun = x.X
+			continue
+		case *ast.IndexListExpr:
+			fun = x.X
+			continue
+		}
+
+		break
+	}
+	switch x := fun.(type) {
+	case *ast.Ident:
+		x.Name = name + SuffixContext
+	case *ast.SelectorExpr:
+		x.Sel.Name = name + SuffixContext
+	}
+}
+
+// insertCompatWrappers adds the deprecated wrappers after their context-aware siblings in the
+// formatted source of filename. It works on a fresh parse so that the inserted text does not
+// depend on positions of the mutated AST.
+func insertCompatWrappers(filename string, src []byte, wrappers []compatWrapper) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+
+	type insertion struct {
+		offset int
+		text   string
+	}
+	var insertions []insertion
+	for _, w := range wrappers {
+		decl := findCompatDecl(file, w)
+		if decl == nil {
+			return nil, fmt.Errorf("locating %s in %s", w.NewName, filename)
+		}
+		insertions = append(insertions, insertion{
+			offset: fset.Position(decl.End()).Offset,
+			text:   "\n\n" + compatWrapperSource(fset, decl, w),
+		})
+	}
+	sort.Slice(insertions, func(i, j int) bool { return insertions[i].offset > insertions[j].offset })
+
+	out := append([]byte(nil), src...)
+	for _, ins := range insertions {
+		out = append(out[:ins.offset], append([]byte(ins.text), out[ins.offset:]...)...)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", filename, err)
+	}
+
+	return formatted, nil
+}
+
+func findCompatDecl(file *ast.File, w compatWrapper) *ast.FuncDecl {
+	for _, d := range file.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if ok && fn.Name.Name == w.NewName && recvBaseName(fn) == w.Recv {
+			return fn
+		}
+	}
+
+	return nil
+}
+
+// compatWrapperSource renders the deprecated wrapper for decl, the context-aware sibling whose
+// first parameter is the added context.
+func compatWrapperSource(fset *token.FileSet, decl *ast.FuncDecl, w compatWrapper) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// %s is like %s but uses context.Background().\n//\n// Deprecated: Use %s instead.\nfunc ", w.Name, w.NewName, w.NewName)
+
+	callee := w.NewName
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		field := decl.Recv.List[0]
+		recvName := "recv"
+		if len(field.Names) > 0 && field.Names[0].Name != "_" {
+			recvName = field.Names[0].Name
+		}
+		fmt.Fprintf(&sb, "(%s %s) ", recvName, nodeSource(fset, field.Type))
+		callee = recvName + "." + w.NewName
+	}
+	sb.WriteString(w.Name)
+
+	if tparams := decl.Type.TypeParams; tparams != nil && len(tparams.List) > 0 {
+		var decls, names []string
+		for _, field := range tparams.List {
+			var fieldNames []string
+			for _, n := range field.Names {
+				fieldNames = append(fieldNames, n.Name)
+			}
+			names = append(names, fieldNames...)
+			decls = append(decls, strings.Join(fieldNames, ", ")+" "+nodeSource(fset, field.Type))
+		}
+		sb.WriteString("[" + strings.Join(decls, ", ") + "]")
+		callee += "[" + strings.Join(names, ", ") + "]"
+	}
+
+	var params, args []string
+	for i, field := range decl.Type.Params.List {
+		if i == 0 {
+			continue // the context parameter added to the sibling
+		}
+		typ := nodeSource(fset, field.Type)
+		_, variadic := field.Type.(*ast.Ellipsis)
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+		for _, n := range names {
+			name := n.Name
+			if name == "_" {
+				name = "arg" + strconv.Itoa(len(args))
+			}
+			params = append(params, name+" "+typ)
+			if variadic {
+				name += "..."
+			}
+			args = append(args, name)
+		}
+	}
+	sb.WriteString("(" + strings.Join(params, ", ") + ")")
+
+	ret := ""
+	if results := decl.Type.Results; results != nil && len(results.List) > 0 {
+		ret = "return "
+		sb.WriteString(" " + nodeSource(fset, results))
+	}
+	callArgs := append([]string{"context.Background()"}, args...)
+	fmt.Fprintf(&sb, " {\n\t%s%s(%s)\n}", ret, callee, strings.Join(callArgs, ", "))
+
+	return sb.String()
+}
+
+// nodeSource prints node as Go source.
+func nodeSource(fset *token.FileSet, node any) string {
+	var buf bytes.Buffer
+	if fl, ok := node.(*ast.FieldList); ok {
+		// A result list prints without parentheses when it is a single unnamed type.
+		if len(fl.List) == 1 && len(fl.List[0].Names) == 0 {
+			return nodeSource(fset, fl.List[0].Type)
+		}
+		var parts []string
+		for _, field := range fl.List {
+			var names []string
+			for _, n := range field.Names {
+				names = append(names, n.Name)
+			}
+			part := nodeSource(fset, field.Type)
+			if len(names) > 0 {
+				part = strings.Join(names, ", ") + " " + part
+			}
+			parts = append(parts, part)
+		}
+
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+	_ = printer.Fprint(&buf, fset, node)
+
+	return buf.String()
+}
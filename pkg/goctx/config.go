@@ -0,0 +1,206 @@
+package goctx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+// ConfigFileYAML and ConfigFileTOML are the names of the project configuration file looked up at
+// the module root. At most one of them may exist.
+const (
+	ConfigFileYAML = ".goctx.yaml"
+	ConfigFileTOML = "goctx.toml"
+)
+
+// Config holds project defaults for goctx, read from .goctx.yaml or goctx.toml at the module root.
+// Command-line flags take precedence over it.
+type Config struct {
+	// CtxName and CtxType are the name and type of the context parameters goctx introduces
+	// (see Options.CtxType).
+	CtxName string `json:"ctx-name" toml:"ctx-name" yaml:"ctx-name"`
+	CtxType string `json:"ctx-type" toml:"ctx-type" yaml:"ctx-type"`
+	// Tags are build tags, as for go build -tags.
+	Tags string `json:"tags" toml:"tags" yaml:"tags"`
+	// StopAt lists stop-at boundaries; relative paths are relative to the configuration file.
+	StopAt []string `json:"stop-at" toml:"stop-at" yaml:"stop-at"`
+	// Include and Exclude restrict the files goctx may modify (see Options.Include).
+	Include []string `json:"include" toml:"include" yaml:"include"`
+	Exclude []string `json:"exclude" toml:"exclude" yaml:"exclude"`
+	// MainCtx is the root context policy for main (see Options.MainCtx).
+	MainCtx string `json:"main-ctx" toml:"main-ctx" yaml:"main-ctx"`
+	// HTTP, Web and CLI enable the built-in boundaries, like --http, --web and --cli.
+	HTTP bool     `json:"http" toml:"http" yaml:"http"`
+	Web  []string `json:"web" toml:"web" yaml:"web"`
+	CLI  bool     `json:"cli" toml:"cli" yaml:"cli"`
+	// Boundaries are boundary rules, as in a --boundary-rules file.
+	Boundaries []BoundaryRule `json:"boundaries" toml:"boundaries" yaml:"boundaries"`
+	// CtxSources are context sources, like --ctx-source; DefaultCtxSources adds
+	// DefaultContextSources after them, like --default-ctx-sources.
+	CtxSources        []ContextSource `json:"ctx-sources" toml:"ctx-sources" yaml:"ctx-sources"`
+	DefaultCtxSources bool            `json:"default-ctx-sources" toml:"default-ctx-sources" yaml:"default-ctx-sources"`
+	// UpgradeStdlib is like --upgrade-stdlib; StdlibUpgrades extend DefaultStdlibUpgrades.
+	UpgradeStdlib  bool            `json:"upgrade-stdlib" toml:"upgrade-stdlib" yaml:"upgrade-stdlib"`
+	StdlibUpgrades []StdlibUpgrade `json:"stdlib-upgrades" toml:"stdlib-upgrades" yaml:"stdlib-upgrades"`
+	// UpgradeSlog and ContextVariants are like --upgrade-slog and --context-variants.
+	UpgradeSlog     bool `json:"upgrade-slog" toml:"upgrade-slog" yaml:"upgrade-slog"`
+	ContextVariants bool `json:"context-variants" toml:"context-variants" yaml:"context-variants"`
+}
+
+// DefaultConfig returns the configuration in effect when no configuration file exists.
+func DefaultConfig() Config {
+	return Config{CtxName: VarNameCtx, CtxType: ContextContext, MainCtx: MainCtxBackground}
+}
+
+// FindProjectConfig returns the path of the configuration file at the root of the module
+// containing dir, or "" when there is none.
+func FindProjectConfig(dir string) (string, error) {
+	root := findModuleRoot(dir)
+	if root == "" {
+		return "", nil
+	}
+	var found []string
+	for _, name := range []string{ConfigFileYAML, ConfigFileTOML} {
+		path := filepath.Join(root, name)
+		if st, err := os.Stat(path); err == nil && !st.IsDir() {
+			found = append(found, path)
+		}
+	}
+	if len(found) > 1 {
+		return "", fmt.Errorf("both %s and %s exist in %s; keep only one", ConfigFileYAML, ConfigFileTOML, root)
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+
+	return found[0], nil
+}
+
+// LoadProjectConfig finds and loads the configuration file of the module containing dir. It
+// returns the defaults and an empty path when there is none.
+func LoadProjectConfig(dir string) (Config, string, error) {
+	path, err := FindProjectConfig(dir)
+	if err != nil || path == "" {
+		return DefaultConfig(), "", err
+	}
+	cfg, err := LoadConfig(path)
+
+	return cfg, path, err
+}
+
+// LoadConfig reads a configuration file, YAML or TOML by extension, on top of the defaults.
+// Unknown keys and invalid values are errors naming the file.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading configuration: %w", err)
+	}
+	switch filepath.Ext(path) {
+	case ".toml":
+		meta, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("parsing %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for i, raw := range cfg.StopAt {
+		cfg.StopAt[i] = resolveConfigPath(dir, raw)
+		if _, err := parseStopSpec(cfg.StopAt[i]); err != nil {
+			return Config{}, fmt.Errorf("invalid configuration %s: stop-at %s: %w", path, raw, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// validate checks every setting that can be checked without loading packages.
+func (c Config) validate() error {
+	if c.CtxName != "" && (!token.IsIdentifier(c.CtxName) || c.CtxName == "_") {
+		return fmt.Errorf("ctx-name: %q is not a Go identifier", c.CtxName)
+	}
+	if c.CtxType != "" && c.CtxType != ContextContext {
+		if _, _, _, err := splitQualifiedType(c.CtxType); err != nil {
+			return fmt.Errorf("ctx-type: invalid ctx type %q: %w", c.CtxType, err)
+		}
+	}
+	if _, err := parseMainCtx(c.MainCtx); err != nil {
+		return fmt.Errorf("main-ctx: %w", err)
+	}
+	if err := validateWeb(c.Web); err != nil {
+		return fmt.Errorf("web: %w", err)
+	}
+	if err := validateBoundaryRules(c.Boundaries); err != nil {
+		return fmt.Errorf("boundaries: %w", err)
+	}
+	if err := validateContextSources(c.CtxSources); err != nil {
+		return fmt.Errorf("ctx-sources: %w", err)
+	}
+	if err := validateStdlibUpgrades(c.StdlibUpgrades); err != nil {
+		return fmt.Errorf("stdlib-upgrades: %w", err)
+	}
+	if _, err := compileGlobs(c.Include); err != nil {
+		return fmt.Errorf("include: %w", err)
+	}
+	if _, err := compileGlobs(c.Exclude); err != nil {
+		return fmt.Errorf("exclude: %w", err)
+	}
+
+	return nil
+}
+
+// ContextSources returns the context sources of the configuration, the defaults last.
+func (c Config) ContextSources() []ContextSource {
+	sources := slices.Clone(c.CtxSources)
+	if c.DefaultCtxSources {
+		sources = append(sources, DefaultContextSources...)
+	}
+
+	return sources
+}
+
+// resolveConfigPath makes the relative directory or file of a stop-at spec relative to dir
+// instead of the working directory.
+func resolveConfigPath(dir, raw string) string {
+	switch {
+	case strings.HasPrefix(raw, StopAtRegexPrefix):
+		return raw
+	case isDirPattern(raw):
+		if filepath.IsAbs(raw) {
+			return raw
+		}
+		rest, recursive := strings.CutSuffix(filepath.ToSlash(raw), "/...")
+		joined := filepath.Join(dir, filepath.FromSlash(rest))
+		if recursive {
+			joined += string(filepath.Separator) + "..."
+		}
+
+		return joined
+	}
+	if m := fileSpecRe.FindStringSubmatch(filepath.ToSlash(raw)); m != nil && strings.HasSuffix(m[1], ".go") && !filepath.IsAbs(m[1]) {
+		return filepath.Join(dir, filepath.FromSlash(m[1])) + ":" + m[2]
+	}
+
+	return raw
+}
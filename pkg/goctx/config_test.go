@@ -0,0 +1,119 @@
+package goctx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	write := func(t *testing.T, dir, name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		return path
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		write(t, dir, "api/api.go", "package api\n")
+		cfg, err := LoadConfig(write(t, dir, ConfigFileYAML, "tags: integration\nhttp: true\nstop-at: [./api/..., 're:^main\\.']\nexclude: ['**/*_gen.go']\n"))
+		require.NoError(t, err)
+		require.Equal(t, Config{
+			CtxName: VarNameCtx,
+			CtxType: ContextContext,
+			MainCtx: MainCtxBackground,
+			Tags:    "integration",
+			HTTP:    true,
+			StopAt:  []string{filepath.Join(dir, "api") + string(filepath.Separator) + "...", `re:^main\.`},
+			Exclude: []string{"**/*_gen.go"},
+		}, cfg)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		t.Parallel()
+		cfg, err := LoadConfig(write(t, t.TempDir(), ConfigFileTOML, "main-ctx = \"signal\"\nweb = [\"gin\"]\n\n[[boundaries]]\ntype = \"echo.Context\"\nexpr = \"{{.Param}}.Request().Context()\"\n"))
+		require.NoError(t, err)
+		require.Equal(t, MainCtxSignal, cfg.MainCtx)
+		require.Equal(t, []string{"gin"}, cfg.Web)
+		require.Equal(t, []BoundaryRule{{Type: "echo.Context", Expr: "{{.Param}}.Request().Context()"}}, cfg.Boundaries)
+	})
+
+	t.Run("ctx sources", func(t *testing.T) {
+		t.Parallel()
+		cfg, err := LoadConfig(write(t, t.TempDir(), ConfigFileYAML, "ctx-sources:\n  - type: '*example.com/app/jobs.Job'\n    expr: '{{.Param}}.Ctx()'\ndefault-ctx-sources: true\n"))
+		require.NoError(t, err)
+		job := ContextSource{Type: "*example.com/app/jobs.Job", Expr: "{{.Param}}.Ctx()"}
+		require.Equal(t, []ContextSource{job}, cfg.CtxSources)
+		require.Equal(t, append([]ContextSource{job}, DefaultContextSources...), cfg.ContextSources())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		_, err := LoadConfig(write(t, dir, "unknown.yaml", "stop_at: [x]\n"))
+		require.ErrorContains(t, err, "field stop_at not found")
+		_, err = LoadConfig(write(t, dir, "unknown.toml", "stop_at = [\"x\"]\n"))
+		require.ErrorContains(t, err, `unknown key "stop_at"`)
+		_, err = LoadConfig(write(t, dir, "web.yaml", "web: [django]\n"))
+		require.ErrorContains(t, err, `web: unknown web framework "django"`)
+		_, err = LoadConfig(write(t, dir, "main.yaml", "main-ctx: forever\n"))
+		require.ErrorContains(t, err, `main-ctx: invalid main-ctx "forever"`)
+		_, err = LoadConfig(write(t, dir, "name.yaml", "ctx-name: 2c\n"))
+		require.ErrorContains(t, err, `ctx-name: "2c" is not a Go identifier`)
+		_, err = LoadConfig(write(t, dir, "type.yaml", "ctx-type: Ctx\n"))
+		require.ErrorContains(t, err, `ctx-type: invalid ctx type "Ctx"`)
+		_, err = LoadConfig(write(t, dir, "sources.yaml", "ctx-sources: [{expr: '{{.Param}}'}]\n"))
+		require.ErrorContains(t, err, "ctx-sources: context source 1: missing type")
+		_, err = LoadConfig(write(t, dir, "upgrades.yaml", "stdlib-upgrades: [{func: example.com/x.Do}]\n"))
+		require.ErrorContains(t, err, "stdlib-upgrades: stdlib upgrade 1 (example.com/x.Do): want exactly one of expr and hint")
+		_, err = LoadConfig(write(t, dir, "stop.yaml", "stop-at: ['re:(']\n"))
+		require.ErrorContains(t, err, "stop-at re:(")
+	})
+
+	t.Run("project", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		write(t, dir, "go.mod", "module example.com/app\n")
+		cfg, path, err := LoadProjectConfig(filepath.Join(dir))
+		require.NoError(t, err)
+		require.Empty(t, path)
+		require.Equal(t, DefaultConfig(), cfg)
+
+		write(t, dir, ConfigFileYAML, "cli: true\n")
+		write(t, dir, ConfigFileTOML, "cli = true\n")
+		_, _, err = LoadProjectConfig(dir)
+		require.ErrorContains(t, err, "both .goctx.yaml and goctx.toml exist")
+	})
+}
+
+func TestFileFilter(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	filter, err := newFileFilter(Options{
+		WorkDir: root,
+		Include: []string{"internal/**", "main.go"},
+		Exclude: []string{"**/*_gen.go", "internal/legacy/**"},
+	})
+	require.NoError(t, err)
+	filter.root = root
+
+	for file, want := range map[string]bool{
+		"main.go":                    true,
+		"cmd/main.go":                false,
+		"internal/db/db.go":          true,
+		"internal/db/models_gen.go":  false,
+		"internal/legacy/old/old.go": false,
+		"internal/legacy.go":         true,
+	} {
+		require.Equal(t, want, filter.allows(filepath.Join(root, filepath.FromSlash(file))), file)
+	}
+}
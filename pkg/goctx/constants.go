@@ -3,3 +3,4 @@ package goctx
 const FuncNameMain = "main"
 const VarNameCtx = "ctx"
 const ContextContext = "context.Context"
+const ExprContextTODO = "context.TODO()"
@@ -0,0 +1,251 @@
+package goctx
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ParamSpec makes goctx thread a request-scoped value of another type, such as a *sql.Tx or a
+// *slog.Logger, instead of a context, with the same traversal, boundaries and call-site rewrites.
+type ParamSpec struct {
+	// Type is the parameter type qualified by import path: database/sql.*Tx, *database/sql.Tx or
+	// go.opentelemetry.io/otel/trace.Span.
+	Type string
+	// Name is the parameter name, e.g. tx.
+	Name string
+	// Root is the expression Name is set to at boundaries (main, tests, handlers, stop-at
+	// functions) and passed where propagation is cut, e.g. db.MustBegin(). It is printed verbatim.
+	// Without it, propagation reaching a boundary other than a stop-at function is an error.
+	Root string
+}
+
+// ctxParam is the name and type of the context parameters goctx introduces. A value of either
+// context.Context or the configured type counts as a context in scope, unless the configured type
+// is narrower than context.Context. With generic set (see ParamSpec), only the configured type does.
+type ctxParam struct {
+	name string
+	// typ is the configured type, nil for context.Context; path and pkgName locate its package.
+	typ     types.Type
+	path    string
+	pkgName string
+	typName string
+	pointer bool
+	// narrow is set when a context.Context cannot be passed as typ, e.g. a domain interface
+	// embedding context.Context with more methods.
+	narrow  bool
+	generic bool
+	root    string
+}
+
+// defaultCtxParam is ctx context.Context.
+var defaultCtxParam = ctxParam{name: VarNameCtx}
+
+// resolveCtxParam checks Options.CtxName and Options.CtxType, or Options.Param, and looks the
+// type up in pkgs or their dependencies. A context type must implement context.Context.
+func resolveCtxParam(opts Options, pkgs []*packages.Package) (ctxParam, error) {
+	if opts.Param != nil {
+		return resolveParamSpec(*opts.Param, pkgs)
+	}
+	cp := defaultCtxParam
+	if opts.CtxName != "" {
+		if !token.IsIdentifier(opts.CtxName) || opts.CtxName == "_" {
+			return ctxParam{}, fmt.Errorf("invalid ctx name %q: not a Go identifier", opts.CtxName)
+		}
+		cp.name = opts.CtxName
+	}
+	raw := strings.TrimSpace(opts.CtxType)
+	if raw == "" || raw == ContextContext {
+		return cp, nil
+	}
+	if err := cp.lookupType(raw, pkgs); err != nil {
+		return ctxParam{}, fmt.Errorf("invalid ctx type %q: %w", raw, err)
+	}
+	var (
+		ctxType  types.Type
+		ctxIface *types.Interface
+	)
+	if ctxPkg := findTypesPackage(pkgs, "context"); ctxPkg != nil {
+		if obj := ctxPkg.Scope().Lookup("Context"); obj != nil {
+			ctxType = obj.Type()
+			ctxIface, _ = ctxType.Underlying().(*types.Interface)
+		}
+	}
+	if ctxIface == nil || !types.Implements(cp.typ, ctxIface) {
+		return ctxParam{}, fmt.Errorf("invalid ctx type %q: it does not implement context.Context", raw)
+	}
+	cp.narrow = !types.AssignableTo(ctxType, cp.typ)
+
+	return cp, nil
+}
+
+// resolveParamSpec resolves the parameter of spec: only values of its type count as in scope.
+func resolveParamSpec(spec ParamSpec, pkgs []*packages.Package) (ctxParam, error) {
+	cp := ctxParam{name: spec.Name, generic: true, root: strings.TrimSpace(spec.Root)}
+	if !token.IsIdentifier(cp.name) || cp.name == "_" {
+		return ctxParam{}, fmt.Errorf("invalid param name %q: not a Go identifier", spec.Name)
+	}
+	if err := cp.lookupType(strings.TrimSpace(spec.Type), pkgs); err != nil {
+		return ctxParam{}, fmt.Errorf("invalid param type %q: %w", spec.Type, err)
+	}
+	if cp.root != "" {
+		if _, err := parser.ParseExpr(cp.root); err != nil {
+			return ctxParam{}, fmt.Errorf("invalid root expression %q: %w", cp.root, err)
+		}
+	}
+
+	return cp, nil
+}
+
+// lookupType sets the type of cp to the type named by raw, found in pkgs or their dependencies.
+func (cp *ctxParam) lookupType(raw string, pkgs []*packages.Package) error {
+	var err error
+	cp.path, cp.typName, cp.pointer, err = splitQualifiedType(raw)
+	if err != nil {
+		return err
+	}
+	found := findTypesPackage(pkgs, cp.path)
+	if found == nil {
+		return fmt.Errorf("package %s is not part of the module or its dependencies", cp.path)
+	}
+	obj, ok := found.Scope().Lookup(cp.typName).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("%s declares no type %s", cp.path, cp.typName)
+	}
+	cp.typ, cp.pkgName = obj.Type(), found.Name()
+	if cp.pointer {
+		cp.typ = types.NewPointer(cp.typ)
+	}
+
+	return nil
+}
+
+// splitQualifiedType splits *path.Name or path.*Name into its parts.
+func splitQualifiedType(raw string) (path, name string, pointer bool, err error) {
+	dot := strings.LastIndex(raw, ".")
+	if dot < 0 {
+		return "", "", false, errors.New("want an import path qualified type such as example.com/app/appctx.Ctx")
+	}
+	path, name = raw[:dot], raw[dot+1:]
+	if strings.HasPrefix(path, "*") {
+		path, pointer = path[1:], true
+	} else if strings.HasPrefix(name, "*") {
+		name, pointer = name[1:], true
+	}
+	if path == "" || !token.IsIdentifier(name) {
+		return "", "", false, errors.New("want an import path qualified type such as example.com/app/appctx.Ctx")
+	}
+
+	return path, name, pointer, nil
+}
+
+// findTypesPackage returns the package with the given path among pkgs and their imports.
+func findTypesPackage(pkgs []*packages.Package, path string) *types.Package {
+	seen := make(map[*types.Package]bool)
+	var visit func(p *types.Package) *types.Package
+	visit = func(p *types.Package) *types.Package {
+		if p == nil || seen[p] {
+			return nil
+		}
+		seen[p] = true
+		if p.Path() == path {
+			return p
+		}
+		for _, imp := range p.Imports() {
+			if found := visit(imp); found != nil {
+				return found
+			}
+		}
+
+		return nil
+	}
+	for _, pkg := range pkgs {
+		if found := visit(pkg.Types); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// isContext reports whether t is context.Context (unless generic) or the configured type.
+func (cp ctxParam) isContext(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	// Compare by name: test variants of a package have distinct, non-identical types.
+	name := types.TypeString(t, func(p *types.Package) string { return p.Path() })
+
+	return (name == ContextContext && !cp.generic) || (cp.typ != nil && name == types.TypeString(cp.typ, func(p *types.Package) string { return p.Path() }))
+}
+
+// accepts reports whether a value of type t can be passed as the context: one of the configured
+// type, or a context.Context unless the configured type is narrower.
+func (cp ctxParam) accepts(t types.Type) bool {
+	if !cp.isContext(t) {
+		return false
+	}
+
+	return !cp.narrow || types.TypeString(t, func(p *types.Package) string { return p.Path() }) != ContextContext
+}
+
+// typeExpr returns the expression of the parameter type in file, declared in package pkgPath,
+// and the import it needs ("" for none).
+func (cp ctxParam) typeExpr(file *ast.File, pkgPath string) (ast.Expr, string) {
+	if cp.typ == nil {
+		return &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("Context")}, "context"
+	}
+	var expr ast.Expr = ast.NewIdent(cp.typName)
+	imp := ""
+	if pkgPath != cp.path {
+		qual := cp.pkgName
+		for _, spec := range file.Imports {
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil && path == cp.path && spec.Name != nil {
+				qual = spec.Name.Name
+			}
+		}
+		expr, imp = &ast.SelectorExpr{X: ast.NewIdent(qual), Sel: ast.NewIdent(cp.typName)}, cp.path
+	}
+	if cp.pointer {
+		expr = &ast.StarExpr{X: expr}
+	}
+
+	return expr, imp
+}
+
+// typeText is the parameter type as written in a file of package pkgPath that imports it under
+// its package name.
+func (cp ctxParam) typeText(pkgPath string) string {
+	expr, _ := cp.typeExpr(&ast.File{}, pkgPath)
+
+	return types.ExprString(expr)
+}
+
+// noun names the parameter in change reasons: "context", or its name for a ParamSpec.
+func (cp ctxParam) noun() string {
+	if cp.generic {
+		return cp.name
+	}
+
+	return "context"
+}
+
+// placeholder returns the expression passed where propagation is cut: expr, context.TODO() by
+// default, or the root expression of a ParamSpec.
+func (cp ctxParam) placeholder(expr string) (string, error) {
+	if !cp.generic {
+		return firstNonEmpty(expr, ExprContextTODO), nil
+	}
+	if expr = firstNonEmpty(expr, cp.root); expr == "" {
+		return "", fmt.Errorf("cannot cut propagation of %s without a root expression", cp.name)
+	}
+
+	return expr, nil
+}
@@ -0,0 +1,112 @@
+package goctx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ContextSource is a type of value that carries a context: a function without a ctx but with a
+// parameter (or receiver field) of type Type passes the context derived with Expr to its callees
+// instead of gaining a ctx parameter itself.
+type ContextSource struct {
+	// Type is the value type, qualified by import path (*net/http.Request) or by package name.
+	Type string `json:"type" toml:"type" yaml:"type"`
+	// Expr is a text/template producing the context expression; {{.Param}} is the value, e.g.
+	// {{.Param}}.Context().
+	Expr string `json:"expr" toml:"expr" yaml:"expr"`
+}
+
+// DefaultContextSources are the sources enabled by --default-ctx-sources: HTTP requests, cobra
+// commands, tests and benchmarks, and contexts stored in receiver fields.
+var DefaultContextSources = []ContextSource{
+	{Type: "*net/http.Request", Expr: "{{.Param}}.Context()"},
+	{Type: "*github.com/spf13/cobra.Command", Expr: "{{.Param}}.Context()"},
+	{Type: "*testing.T", Expr: "{{.Param}}.Context()"},
+	{Type: "*testing.B", Expr: "{{.Param}}.Context()"},
+	{Type: "*testing.F", Expr: "{{.Param}}.Context()"},
+	{Type: "testing.TB", Expr: "{{.Param}}.Context()"},
+	{Type: ContextContext, Expr: "{{.Param}}"},
+}
+
+// ParseContextSource parses TYPE=EXPR, e.g. *net/http.Request={{.Param}}.Context().
+func ParseContextSource(raw string) (ContextSource, error) {
+	typ, expr, ok := strings.Cut(raw, "=")
+	if !ok {
+		return ContextSource{}, fmt.Errorf("invalid context source %q, want TYPE=EXPR", raw)
+	}
+	src := ContextSource{Type: strings.TrimSpace(typ), Expr: strings.TrimSpace(expr)}
+
+	return src, validateContextSources([]ContextSource{src})
+}
+
+// validateContextSources checks that every source names a type and renders a valid Go expression.
+func validateContextSources(sources []ContextSource) error {
+	for i, src := range sources {
+		if src.Type == "" {
+			return fmt.Errorf("context source %d: missing type", i+1)
+		}
+		if _, err := src.rule().derive("v"); err != nil {
+			return fmt.Errorf("context source %d (%s): %w", i+1, src.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// rule returns the boundary rule rendering the same expression.
+func (s ContextSource) rule() BoundaryRule {
+	return BoundaryRule{Type: s.Type, Expr: s.Expr}
+}
+
+// ctxSourceInScope returns the context expression derived from a parameter or receiver field of
+// fn matching one of sources, or "". Sources are tried in order; for each, parameters come
+// before receiver fields.
+func ctxSourceInScope(fn *ast.FuncDecl, pkg *packages.Package, sources []ContextSource) (string, error) {
+	if len(sources) == 0 || fn == nil || fn.Type == nil {
+		return "", nil
+	}
+	type value struct {
+		expr string
+		typ  types.Type
+	}
+	var values []value
+	if fn.Type.Params != nil {
+		for _, field := range fn.Type.Params.List {
+			for _, name := range field.Names {
+				if name.Name != "_" {
+					values = append(values, value{expr: name.Name, typ: pkg.TypesInfo.TypeOf(field.Type)})
+				}
+			}
+		}
+	}
+	if fn.Recv != nil && len(fn.Recv.List) == 1 && len(fn.Recv.List[0].Names) == 1 && fn.Recv.List[0].Names[0].Name != "_" {
+		recv := fn.Recv.List[0].Names[0].Name
+		recvType := pkg.TypesInfo.TypeOf(fn.Recv.List[0].Type)
+		if ptr, ok := recvType.(*types.Pointer); ok {
+			recvType = ptr.Elem()
+		}
+		var st *types.Struct
+		if recvType != nil {
+			st, _ = recvType.Underlying().(*types.Struct)
+		}
+		if st != nil {
+			for i := range st.NumFields() {
+				values = append(values, value{expr: recv + "." + st.Field(i).Name(), typ: st.Field(i).Type()})
+			}
+		}
+	}
+
+	for _, src := range sources {
+		for _, v := range values {
+			if typeMatches(src.Type, v.typ) {
+				return src.rule().derive(v.expr)
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,25 @@
+package goctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContextSource(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseContextSource("*example.com/app/jobs.Job = {{.Param}}.Ctx()")
+	require.NoError(t, err)
+	require.Equal(t, ContextSource{Type: "*example.com/app/jobs.Job", Expr: "{{.Param}}.Ctx()"}, got)
+
+	for raw, want := range map[string]string{
+		"*net/http.Request":               "want TYPE=EXPR",
+		"={{.Param}}.Context()":           "missing type",
+		"*net/http.Request={{.Param}}.(":  "context source 1 (*net/http.Request)",
+		"*net/http.Request={{.Param}.Ctx": "context source 1 (*net/http.Request)",
+	} {
+		_, err := ParseContextSource(raw)
+		require.ErrorContains(t, err, want, raw)
+	}
+}
@@ -1,6 +1,8 @@
 package goctx
 
 import (
+	"context"
+	"errors"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -51,15 +53,25 @@ func inputDir(t *testing.T) string {
 func writeTempModuleFromInput(t *testing.T) string {
 	t.Helper()
 
-	src := filepath.Join(inputDir(t), t.Name())
+	return writeTempModuleFrom(t, t.Name())
+}
+
+// writeTempModuleFrom is writeTempModuleFromInput for the input directory of the named test case.
+func writeTempModuleFrom(t *testing.T, name string) string {
+	t.Helper()
+
+	src := filepath.Join(inputDir(t), name)
 	dst := t.TempDir()
 
 	// Copy all files from src to dst, preserving relative paths
 	require.NoError(t, copyDir(t, src, dst))
 
-	// Always write a minimal go.mod to allow packages.Load to work in that dir
+	// Write a minimal go.mod to allow packages.Load to work in that dir, unless the input brings
+	// its own (e.g. replacing framework modules with local stubs).
 	gomod := filepath.Join(dst, "go.mod")
-	require.NoError(t, os.WriteFile(gomod, []byte("module example.com/e2e\n\ngo 1.21\n"), 0o644))
+	if _, err := os.Stat(gomod); errors.Is(err, fs.ErrNotExist) {
+		require.NoError(t, os.WriteFile(gomod, []byte("module example.com/e2e\n\ngo 1.21\n"), 0o644))
+	}
 
 	return dst
 }
@@ -365,3 +377,574 @@ func TestE2E_OnlyOneCtxParam_WhenCallingTwoCallees(t *testing.T) {
 	b := fsutils.MustRead(filepath.Join(dir, "main.go"))
 	g.Assert(t, "main.go", normalizeNewlines(b))
 }
+
+// readAllFiles returns the contents of every file under dir keyed by relative path.
+func readAllFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	out := make(map[string]string)
+	require.NoError(t, filepath.WalkDir(dir, func(path string, dirEntry fs.DirEntry, err error) error {
+		if err != nil || dirEntry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		out[rel] = string(fsutils.MustRead(path))
+
+		return nil
+	}))
+
+	return out
+}
+
+func TestE2E_Run_CancelledContext_WritesNothing(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTempModuleFromInput(t)
+	target := filepath.Join(dir, "a", "b.go") + ":Callee"
+	before := readAllFiles(t, dir)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := Run(ctx, Options{Target: target, WorkDir: dir})
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorContains(t, err, "loading packages interrupted")
+	require.Equal(t, before, readAllFiles(t, dir))
+}
+
+func TestE2E_TargetGrammar_EquivalentForms(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	const fixture = "TestE2E_Distinguish_Functions_Methods_And_Qualified/1"
+
+	// Each new form must rewrite the module exactly like the legacy file.go:Func:N form.
+	cases := []struct {
+		name, legacy, target string
+	}{
+		{"pointer-method", "main.go:MyFunc:11", "main.go:(*TypeA).MyFunc"},
+		{"value-method", "main.go:MyFunc:19", "main.go:TypeB.MyFunc"},
+		{"method-with-line", "main.go:MyFunc:19", "main.go:TypeB.MyFunc:19"},
+		{"qualified-func", "main.go:MyFunc:25", "example.com/e2e.MyFunc"},
+		{"qualified-method", "main.go:MyFunc:11", "example.com/e2e.(*TypeA).MyFunc"},
+		{"qualified-other-pkg", "xyz/xyz.go:MyFunc", "example.com/e2e/xyz.MyFunc"},
+		{"cursor-line", "main.go:MyFunc:25", "main.go:#L26"},
+		{"cursor-position", "main.go:MyFunc:11", "main.go:12:3"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			withPath := func(dir, target string) string {
+				if strings.Contains(target, ":") {
+					return filepath.Join(dir, target)
+				}
+
+				return target
+			}
+			wantDir := writeTempModuleFrom(t, fixture)
+			require.NoError(t, Run(ctx, Options{Target: withPath(wantDir, tc.legacy), WorkDir: wantDir}))
+			gotDir := writeTempModuleFrom(t, fixture)
+			require.NoError(t, Run(ctx, Options{Target: withPath(gotDir, tc.target), WorkDir: gotDir}))
+
+			require.Equal(t, readAllFiles(t, wantDir), readAllFiles(t, gotDir))
+		})
+	}
+}
+
+func TestE2E_TargetGrammar_ErrorsListCandidates(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	dir := writeTempModuleFrom(t, "TestE2E_Distinguish_Functions_Methods_And_Qualified/1")
+
+	_, err := Plan(ctx, Options{Target: filepath.Join(dir, "main.go") + ":MyFunc", WorkDir: dir})
+	require.ErrorContains(t, err, "ambiguous target")
+	require.ErrorContains(t, err, "main.go:(*TypeA).MyFunc:11")
+	require.ErrorContains(t, err, "main.go:TypeB.MyFunc:19")
+	require.ErrorContains(t, err, "main.go:MyFunc:25")
+
+	_, err = Plan(ctx, Options{Target: filepath.Join(dir, "main.go") + ":TypeC.MyFunc", WorkDir: dir})
+	require.ErrorContains(t, err, "candidates with the same name")
+	require.ErrorContains(t, err, "main.go:(*TypeA).MyFunc:11")
+
+	_, err = Plan(ctx, Options{Target: "example.com/nope.MyFunc", WorkDir: dir})
+	require.ErrorContains(t, err, "could not find a loaded package declaring example.com/nope.MyFunc")
+
+	_, err = Plan(ctx, Options{Target: filepath.Join(dir, "main.go") + ":#L8", WorkDir: dir})
+	require.ErrorContains(t, err, "no function or method declaration encloses")
+}
+
+func TestE2E_MultipleTargets(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	dbFile := filepath.Join(dir, "db", "db.go")
+
+	// ListUsers calls GetUser, and both are targets; the same target given twice is handled once.
+	require.NoError(t, Run(ctx, Options{
+		Target:  dbFile + ":GetUser",
+		Targets: []string{dbFile + ":ListUsers", "example.com/e2e/db.GetUser"},
+		WorkDir: dir,
+	}))
+
+	g.Assert(t, "db.go", normalizeNewlines(fsutils.MustRead(dbFile)))
+	g.Assert(t, "main.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+}
+
+func TestE2E_StopAt_PatternsAndRegex(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	files := []string{"store/store.go", "svc/svc.go", "internal/api/server.go", "internal/jobs/nightly/nightly.go", "main.go"}
+	apiMethods := `re:^\(\*example\.com/e2e/internal/api\.Server\)\.[A-Z]`
+
+	dir := writeTempModuleFromInput(t)
+	require.NoError(t, Run(ctx, Options{
+		Target:  filepath.Join(dir, "store", "store.go") + ":Load",
+		StopAts: []string{filepath.Join(dir, "internal", "jobs") + "/...", apiMethods},
+		WorkDir: dir,
+	}))
+	for _, f := range files {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+
+	// An import-path pattern and a receiver-qualified name select the same boundaries.
+	other := writeTempModuleFromInput(t)
+	require.NoError(t, Run(ctx, Options{
+		Target:  filepath.Join(other, "store", "store.go") + ":Load",
+		StopAt:  "example.com/e2e/internal/jobs/...",
+		StopAts: []string{filepath.Join(other, "internal", "api", "server.go") + ":(*Server).Get"},
+		WorkDir: other,
+	}))
+	require.Equal(t, readAllFiles(t, dir), readAllFiles(t, other))
+}
+
+func TestE2E_PreserveExported(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	plan, err := Plan(ctx, Options{
+		Target:           filepath.Join(dir, "internal", "db", "db.go") + ":Query",
+		WorkDir:          dir,
+		PreserveExported: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	var debt strings.Builder
+	for _, d := range plan.Debt {
+		rel, err := filepath.Rel(dir, d.Pos.Filename)
+		require.NoError(t, err)
+		d.Pos.Filename = filepath.ToSlash(rel)
+		debt.WriteString(d.String() + "\n")
+	}
+	g.Assert(t, "debt.txt", []byte(debt.String()))
+	for _, f := range []string{"internal/db/db.go", "lib/lib.go", "main.go"} {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+}
+
+func TestE2E_CompatWrappers(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	plan, err := Plan(ctx, Options{
+		Target:         filepath.Join(dir, "store", "store.go") + ":lookup",
+		WorkDir:        dir,
+		CompatWrappers: true,
+	})
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	// Callers without a context keep using the deprecated wrappers.
+	require.Equal(t, []string{filepath.Join(dir, "store", "store.go")}, plan.Files())
+	g.Assert(t, "store.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "store", "store.go"))))
+}
+
+func TestE2E_MaxDepthAndMaxChanges(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	target := filepath.Join(dir, "main.go") + ":leaf"
+
+	// Without a depth limit, leaf, a, b, c and main would all change.
+	before := readAllFiles(t, dir)
+	_, err := Plan(ctx, Options{Target: target, WorkDir: dir, MaxChanges: 4})
+	require.ErrorContains(t, err, "plan would modify 5 functions, more than the maximum of 4")
+	require.Equal(t, before, readAllFiles(t, dir))
+
+	plan, err := Plan(ctx, Options{Target: target, WorkDir: dir, MaxDepth: 2, MaxChanges: 4})
+	require.NoError(t, err)
+	require.Len(t, plan.Functions(), 4)
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	var truncated strings.Builder
+	for _, tr := range plan.Truncated {
+		rel, err := filepath.Rel(dir, tr.Pos.Filename)
+		require.NoError(t, err)
+		tr.Pos.Filename = filepath.ToSlash(rel)
+		truncated.WriteString(tr.String() + "\n")
+	}
+	g.Assert(t, "truncated.txt", []byte(truncated.String()))
+	g.Assert(t, "main.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+}
+
+func TestE2E_BoundaryRules(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	rules, err := LoadBoundaryRules(filepath.Join(dir, "rules.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, Run(ctx, Options{
+		Target:        filepath.Join(dir, "app", "app.go") + ":load",
+		WorkDir:       dir,
+		BoundaryRules: rules,
+	}))
+
+	g.Assert(t, "app.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "app", "app.go"))))
+	g.Assert(t, "main.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+}
+
+func TestE2E_CLIBoundaries(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	plan, err := Plan(ctx, Options{Target: filepath.Join(dir, "app", "app.go") + ":Load", WorkDir: dir, CLI: true})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	// Propagation stops inside the framework callbacks: constructors and main keep their signatures.
+	require.Equal(t, []string{
+		filepath.Join(dir, "app", "app.go"),
+		filepath.Join(dir, "cmds", "cobra.go"),
+		filepath.Join(dir, "cmds", "urfave.go"),
+	}, plan.Files())
+	for _, f := range []string{"cmds/cobra.go", "cmds/urfave.go"} {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+}
+
+func TestE2E_WebBoundaries(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	plan, err := Plan(ctx, Options{
+		Target:  filepath.Join(dir, "store", "store.go") + ":Load",
+		WorkDir: dir,
+		Web:     []string{"chi", "echo", "fiber", "gin"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	// Every handler derives its own ctx: Register and main keep their signatures.
+	require.Equal(t, []string{
+		filepath.Join(dir, "handlers", "handlers.go"),
+		filepath.Join(dir, "store", "store.go"),
+	}, plan.Files())
+	g.Assert(t, "handlers.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "handlers", "handlers.go"))))
+
+	_, err = Plan(ctx, Options{Target: filepath.Join(dir, "store", "store.go") + ":Load", WorkDir: dir, Web: []string{"martini"}})
+	require.ErrorContains(t, err, `unknown web framework "martini"`)
+}
+
+func TestE2E_MainCtx(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	for golden, policy := range map[string]string{
+		"main_todo.go":    MainCtxTODO,
+		"main_signal.go":  MainCtxSignal,
+		"main_timeout.go": MainCtxTimeoutPrefix + "90s",
+	} {
+		dir := writeTempModuleFromInput(t)
+		require.NoError(t, Run(ctx, Options{Target: filepath.Join(dir, "main.go") + ":work", WorkDir: dir, MainCtx: policy}))
+		g.Assert(t, golden, normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+	}
+
+	dir := writeTempModuleFromInput(t)
+	err := Run(ctx, Options{Target: filepath.Join(dir, "main.go") + ":work", WorkDir: dir, MainCtx: "timeout:soon"})
+	require.ErrorContains(t, err, `invalid main-ctx "timeout:soon"`)
+}
+
+func TestE2E_MainCtxNames(t *testing.T) {
+	t.Parallel()
+
+	// main declares cancel, the package signal and the file imports time as tm.
+	ctx := t.Context()
+	g := genGoldie(t)
+	for golden, policy := range map[string]string{
+		"main_signal.go":  MainCtxSignal,
+		"main_timeout.go": MainCtxTimeoutPrefix + "90s",
+	} {
+		dir := writeTempModuleFromInput(t)
+		require.NoError(t, Run(ctx, Options{Target: filepath.Join(dir, "main.go") + ":work", WorkDir: dir, MainCtx: policy}))
+		g.Assert(t, golden, normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+	}
+}
+
+func TestE2E_ProjectConfig(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	cfg, path, err := LoadProjectConfig(filepath.Join(dir, "store"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, ConfigFileYAML), path)
+	opts := Options{
+		Target:  filepath.Join(dir, "store", "store.go") + ":Get",
+		WorkDir: dir,
+		StopAts: cfg.StopAt,
+		Exclude: cfg.Exclude,
+		MainCtx: cfg.MainCtx,
+	}
+
+	before := readAllFiles(t, dir)
+	_, err = Plan(ctx, opts)
+	require.ErrorContains(t, err, "outside the include/exclude patterns: "+filepath.Join(dir, "gen", "gen.go")+" (example.com/e2e/gen.Gen)")
+	require.Equal(t, before, readAllFiles(t, dir))
+
+	// A flag given on the command line replaces the configured patterns.
+	opts.Exclude = []string{"**/*_gen.go"}
+	require.NoError(t, Run(ctx, opts))
+
+	g.Assert(t, "api.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "api", "api.go"))))
+	g.Assert(t, "main.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+}
+
+func TestE2E_CtxNameAndType(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	opts := Options{
+		Target:  filepath.Join(dir, "store", "store.go") + ":Load",
+		WorkDir: dir,
+		CtxName: "c",
+		CtxType: "example.com/e2e/appctx.Ctx",
+		HTML:    true,
+		// The boundaries build the domain context from the request's.
+		BoundaryRules: []BoundaryRule{{Type: "*example.com/e2e/web.Request", Expr: "appctx.New({{.Param}}.Context())"}},
+	}
+
+	bad := opts
+	bad.CtxType = "*example.com/e2e/web.Request"
+	_, err := Plan(ctx, bad)
+	require.ErrorContains(t, err, `invalid ctx type "*example.com/e2e/web.Request": it does not implement context.Context`)
+	bad.CtxType = "example.com/e2e/missing.Ctx"
+	_, err = Plan(ctx, bad)
+	require.ErrorContains(t, err, "package example.com/e2e/missing is not part of the module or its dependencies")
+
+	// A net/http handler derives a context.Context, which is no appctx.Ctx.
+	_, err = Plan(ctx, opts)
+	require.ErrorContains(t, err, "propagation reached Handle, a http boundary, which derives a context.Context, not a appctx.Ctx")
+
+	opts.BoundaryRules = append(opts.BoundaryRules, BoundaryRule{Type: "*net/http.Request", Expr: "appctx.New({{.Param}}.Context())"})
+	require.NoError(t, Run(ctx, opts))
+	for _, f := range []string{"store/store.go", "svc/svc.go", "api/api.go"} {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+}
+
+func TestE2E_Param(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	opts := Options{
+		Target:  filepath.Join(dir, "repo", "repo.go") + ":Insert",
+		WorkDir: dir,
+		Param:   &ParamSpec{Type: "database/sql.*Tx", Name: "tx"},
+	}
+
+	_, err := Plan(ctx, opts)
+	require.ErrorContains(t, err, "propagation of tx reached main, a main boundary; give a root expression to create tx there")
+
+	opts.Param.Root = "mustBegin(db)"
+	plan, err := Plan(ctx, opts)
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	for _, f := range []string{"repo/repo.go", "svc/svc.go", "main.go"} {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+}
+
+func TestE2E_ContextSources(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	opts := Options{
+		Target:         filepath.Join(dir, "store", "store.go") + ":Load",
+		WorkDir:        dir,
+		ContextSources: append([]ContextSource{{Type: "*example.com/e2e/jobs.Job", Expr: "{{.Param}}.Ctx()"}}, DefaultContextSources...),
+	}
+
+	plan, err := Plan(ctx, opts)
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	for _, f := range []string{"store/store.go", "svc/svc.go", "main.go"} {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+
+	diags, err := Check(ctx, Options{WorkDir: dir, ContextSources: opts.ContextSources}, []string{"./..."})
+	require.NoError(t, err)
+	require.Empty(t, diags)
+}
+
+func TestE2E_Unstore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	_, err := Plan(ctx, Options{WorkDir: dir, Unstore: "Worker.name"})
+	require.ErrorContains(t, err, "unstoring Worker.name: field Worker.name is of type string, not a context")
+	_, err = Plan(ctx, Options{WorkDir: dir, Unstore: "Worker.missing"})
+	require.ErrorContains(t, err, "no struct type Worker with a field missing found")
+
+	plan, err := Plan(ctx, Options{WorkDir: dir, Unstore: "example.com/e2e/worker.Worker.ctx"})
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+
+	for _, f := range []string{"worker/worker.go", "worker/worker_test.go", "main.go"} {
+		g.Assert(t, filepath.Base(f), normalizeNewlines(fsutils.MustRead(filepath.Join(dir, f))))
+	}
+}
+
+func TestE2E_UpgradeStdlib(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	opts := Options{
+		WorkDir:        dir,
+		StdlibUpgrades: []StdlibUpgrade{{Func: "example.com/e2e/legacy.Do", Expr: "{{.Pkg}}.DoCtx({{.Ctx}}, {{.Args}})"}},
+	}
+
+	// During propagation, only the functions that gain a ctx are upgraded.
+	propagate := opts
+	propagate.Target = filepath.Join(dir, "store", "store.go") + ":Names"
+	propagate.UpgradeStdlib = true
+	plan, err := Plan(ctx, propagate)
+	require.NoError(t, err)
+	g.Assert(t, "propagate.txt", renderEdits(t, dir, plan))
+
+	bad := opts
+	bad.StdlibUpgrades = []StdlibUpgrade{{Func: "net/http.Get", Expr: "{{.Nope}}"}}
+	_, err = UpgradeStdlib(ctx, bad, nil)
+	require.ErrorContains(t, err, "stdlib upgrade 1 (net/http.Get): rendering expr")
+
+	plan, err = UpgradeStdlib(ctx, opts, []string{"./store"})
+	require.NoError(t, err)
+	g.Assert(t, "upgrade.txt", append(renderEdits(t, dir, plan), renderManual(t, dir, plan)...))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+	g.Assert(t, "store.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "store", "store.go"))))
+}
+
+func TestE2E_Slog(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	// Without propagation, only the functions with a ctx in scope are upgraded.
+	plan, err := UpgradeSlog(ctx, Options{WorkDir: dir}, nil)
+	require.NoError(t, err)
+	g.Assert(t, "upgrade.txt", renderEdits(t, dir, plan))
+
+	plan, err = UpgradeSlog(ctx, Options{WorkDir: dir, PropagateToLoggers: true}, nil)
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+	g.Assert(t, "svc.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "svc", "svc.go"))))
+	g.Assert(t, "main.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+}
+
+func TestE2E_ContextVariants(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	// During propagation, only the functions that gain a ctx switch to the variants.
+	plan, err := Plan(ctx, Options{WorkDir: dir, Target: filepath.Join(dir, "svc", "svc.go") + ":helper", ContextVariants: true})
+	require.NoError(t, err)
+	g.Assert(t, "propagate.txt", renderEdits(t, dir, plan))
+
+	plan, err = UpgradeStdlib(ctx, Options{WorkDir: dir, ContextVariants: true}, nil)
+	require.NoError(t, err)
+	g.Assert(t, "upgrade.txt", append(renderEdits(t, dir, plan), renderManual(t, dir, plan)...))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+	g.Assert(t, "svc.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "svc", "svc.go"))))
+}
+
+func TestE2E_ErrGroup(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	plan, err := Plan(ctx, Options{WorkDir: dir, Target: filepath.Join(dir, "work", "work.go") + ":handle"})
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+	g.Assert(t, "work.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "work", "work.go"))))
+}
+
+func TestE2E_Remove(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+
+	_, err := Remove(ctx, Options{WorkDir: dir, Target: filepath.Join(dir, "store", "store.go") + ":Put"})
+	require.ErrorContains(t, err, "cannot remove the context of example.com/e2e/store.Put: it is used in its body")
+
+	plan, err := Remove(ctx, Options{WorkDir: dir, Target: filepath.Join(dir, "store", "store.go") + ":Get"})
+	require.NoError(t, err)
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+	g.Assert(t, "store.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "store", "store.go"))))
+	g.Assert(t, "svc.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "svc", "svc.go"))))
+	g.Assert(t, "main.go", normalizeNewlines(fsutils.MustRead(filepath.Join(dir, "main.go"))))
+}
@@ -0,0 +1,238 @@
+package goctx
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// typeErrgroupGroup is the group of golang.org/x/sync/errgroup; its zero value runs goroutines
+// without a context, so their calls cannot be cancelled when one of them fails.
+const typeErrgroupGroup = "golang.org/x/sync/errgroup.Group"
+
+// errgroupCtxName is the name of the context derived with errgroup.WithContext, suffixed with a
+// number when taken.
+const errgroupCtxName = "gctx"
+
+// convertErrGroups turns the zero-value errgroup.Group variables of the functions propagation
+// modified into groups created by errgroup.WithContext, when the closures started with Go or
+// TryGo were given the ctx of the function: they get the context of the group instead.
+func convertErrGroups(pkgs []*packages.Package, cp ctxParam, modifiedFiles map[string]bool, changes *changeLog) {
+	// errgroup.WithContext returns a context.Context, not a configured context type.
+	if cp.generic || cp.typ != nil {
+		return
+	}
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil || len(changes.reasons[fn]) == 0 {
+					continue
+				}
+				if convertErrGroupsInFunc(pkg, fn, cp, changes) {
+					markFileModified(modifiedFiles, pkg.Fset, file)
+				}
+			}
+		}
+	}
+}
+
+// errGroupVar is a var g errgroup.Group statement and the closures started on g that were
+// passed the ctx of the function.
+type errGroupVar struct {
+	stmt *ast.DeclStmt
+	name *ast.Ident
+	// ctxUses are the references to ctx inside the closures.
+	ctxUses []*ast.Ident
+}
+
+// convertErrGroupsInFunc converts the errgroup.Group variables of fn. It reports whether fn was
+// modified.
+func convertErrGroupsInFunc(pkg *packages.Package, fn *ast.FuncDecl, cp ctxParam, changes *changeLog) bool {
+	ctxName := getCtxIdentInScope(fn, pkg, cp)
+	if ctxName == "" {
+		return false
+	}
+	var groups []*errGroupVar
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if stmt, ok := n.(*ast.DeclStmt); ok {
+			if g := zeroErrGroup(pkg, stmt); g != nil {
+				groups = append(groups, g)
+			}
+		}
+
+		return true
+	})
+	if len(groups) == 0 {
+		return false
+	}
+
+	taken := make(map[string]bool)
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			taken[id.Name] = true
+		}
+
+		return true
+	})
+
+	replacements := make(map[ast.Stmt]ast.Stmt)
+	for _, g := range groups {
+		if !errGroupConvertible(pkg, fn, g, ctxName, cp) {
+			continue
+		}
+		gctx := errgroupCtxName
+		for i := 2; taken[gctx]; i++ {
+			gctx = errgroupCtxName + strconv.Itoa(i)
+		}
+		taken[gctx] = true
+		for _, id := range g.ctxUses {
+			id.Name = gctx
+		}
+		replacements[g.stmt] = withContextStmt(g, gctx, ctxName)
+		changes.note(fn, "create %s with errgroup.WithContext and pass %s in its goroutines", g.name.Name, gctx)
+	}
+	if len(replacements) == 0 {
+		return false
+	}
+	astutil.Apply(fn.Body, func(c *astutil.Cursor) bool {
+		if stmt, ok := c.Node().(ast.Stmt); ok && replacements[stmt] != nil {
+			c.Replace(replacements[stmt])
+		}
+
+		return true
+	}, nil)
+
+	return true
+}
+
+// zeroErrGroup returns stmt as an errGroupVar when it declares a single errgroup.Group without a
+// value.
+func zeroErrGroup(pkg *packages.Package, stmt *ast.DeclStmt) *errGroupVar {
+	gen, ok := stmt.Decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.VAR || len(gen.Specs) != 1 {
+		return nil
+	}
+	spec, ok := gen.Specs[0].(*ast.ValueSpec)
+	if !ok || len(spec.Names) != 1 || len(spec.Values) != 0 || spec.Type == nil {
+		return nil
+	}
+	obj := pkg.TypesInfo.Defs[spec.Names[0]]
+	if obj == nil || types.TypeString(obj.Type(), func(p *types.Package) string { return p.Path() }) != typeErrgroupGroup {
+		return nil
+	}
+	if _, ok := spec.Type.(*ast.SelectorExpr); !ok {
+		return nil
+	}
+
+	return &errGroupVar{stmt: stmt, name: spec.Names[0]}
+}
+
+// errGroupConvertible collects the references to ctx in the closures started on g and reports
+// whether g can become a pointer returned by errgroup.WithContext: it is only used to call its
+// methods, and propagation passed ctx to at least one of its closures.
+func errGroupConvertible(pkg *packages.Package, fn *ast.FuncDecl, g *errGroupVar, ctxName string, cp ctxParam) bool {
+	obj := pkg.TypesInfo.Defs[g.name]
+	uses, methodCalls := 0, 0
+	passed := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if pkg.TypesInfo.Uses[n] == obj {
+				uses++
+			}
+		case *ast.SelectorExpr:
+			if id, ok := n.X.(*ast.Ident); ok && pkg.TypesInfo.Uses[id] == obj {
+				methodCalls++
+			}
+		case *ast.CallExpr:
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "Go" && sel.Sel.Name != "TryGo") || len(n.Args) != 1 {
+				return true
+			}
+			if id, ok := sel.X.(*ast.Ident); !ok || pkg.TypesInfo.Uses[id] != obj {
+				return true
+			}
+			lit, ok := n.Args[0].(*ast.FuncLit)
+			if !ok {
+				return true
+			}
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if !ok || id.Name != ctxName {
+					return true
+				}
+				switch {
+				case !id.Pos().IsValid():
+					// Passed by propagation.
+					g.ctxUses = append(g.ctxUses, id)
+					passed = true
+				case isOuterCtx(pkg, id, lit, cp):
+					g.ctxUses = append(g.ctxUses, id)
+				}
+
+				return true
+			})
+		}
+
+		return true
+	})
+
+	return passed && uses == methodCalls && ctxDeclaredBefore(pkg, fn, g.stmt, ctxName)
+}
+
+// ctxDeclaredBefore reports whether the context named ctxName is in scope at stmt: declared
+// before it, or introduced by goctx as a parameter or at the start of fn.
+func ctxDeclaredBefore(pkg *packages.Package, fn *ast.FuncDecl, stmt ast.Stmt, ctxName string) bool {
+	if scope := pkg.Types.Scope().Innermost(stmt.Pos()); scope != nil {
+		if _, obj := scope.LookupParent(ctxName, stmt.Pos()); obj != nil {
+			return true
+		}
+	}
+	declaredAfter := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == ctxName && id.Pos() > stmt.Pos() && pkg.TypesInfo.Defs[id] != nil {
+			declaredAfter = true
+		}
+
+		return !declaredAfter
+	})
+
+	return !declaredAfter
+}
+
+// isOuterCtx reports whether id refers to a context declared outside lit.
+func isOuterCtx(pkg *packages.Package, id *ast.Ident, lit *ast.FuncLit, cp ctxParam) bool {
+	v, ok := pkg.TypesInfo.Uses[id].(*types.Var)
+
+	return ok && cp.isContext(v.Type()) && (v.Pos() < lit.Pos() || v.Pos() >= lit.End())
+}
+
+// withContextStmt returns g, gctx := errgroup.WithContext(ctx), positioned at the declaration it
+// replaces so that its comments stay in place.
+func withContextStmt(g *errGroupVar, gctx, ctxName string) ast.Stmt {
+	spec := g.stmt.Decl.(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+	qualifier := spec.Type.(*ast.SelectorExpr).X
+	pos := g.stmt.Pos()
+
+	return &ast.AssignStmt{
+		Lhs:    []ast.Expr{&ast.Ident{NamePos: pos, Name: g.name.Name}, &ast.Ident{NamePos: pos, Name: gctx}},
+		TokPos: pos,
+		Tok:    token.DEFINE,
+		Rhs: []ast.Expr{&ast.CallExpr{
+			Fun:    &ast.SelectorExpr{X: &ast.Ident{NamePos: pos, Name: nodeSource(token.NewFileSet(), qualifier)}, Sel: ast.NewIdent("WithContext")},
+			Lparen: pos,
+			Args:   []ast.Expr{ast.NewIdent(ctxName)},
+			Rparen: pos,
+		}},
+	}
+}
@@ -0,0 +1,128 @@
+package goctx
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// fileFilter restricts the files goctx may modify to those matching Options.Include (when set)
+// and not matching Options.Exclude.
+type fileFilter struct {
+	root             string
+	include, exclude []*regexp.Regexp
+}
+
+// newFileFilter compiles the include and exclude patterns of opts, relative to the module root.
+func newFileFilter(opts Options) (*fileFilter, error) {
+	include, err := compileGlobs(opts.Include)
+	if err != nil {
+		return nil, fmt.Errorf("parsing include patterns: %w", err)
+	}
+	exclude, err := compileGlobs(opts.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("parsing exclude patterns: %w", err)
+	}
+	root := findModuleRoot(firstNonEmpty(opts.WorkDir, "."))
+
+	return &fileFilter{root: root, include: include, exclude: exclude}, nil
+}
+
+// allows reports whether filename may be modified.
+func (f *fileFilter) allows(filename string) bool {
+	if f == nil || (len(f.include) == 0 && len(f.exclude) == 0) {
+		return true
+	}
+	rel, err := filepath.Rel(f.root, filename)
+	if err != nil {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	for _, re := range f.exclude {
+		if re.MatchString(rel) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkFilteredFiles fails when plan modifies a file the filter does not allow, naming the files
+// and the functions changed in them.
+func checkFilteredFiles(plan *ChangePlan, filter *fileFilter) error {
+	var files []string
+	funcs := make(map[string][]string)
+	for _, e := range plan.Edits {
+		if filter.allows(e.File) {
+			continue
+		}
+		if _, ok := funcs[e.File]; !ok {
+			files = append(files, e.File)
+			funcs[e.File] = nil
+		}
+		if e.Function != "" && !slices.Contains(funcs[e.File], e.Function) {
+			funcs[e.File] = append(funcs[e.File], e.Function)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	denied := make([]string, 0, len(files))
+	for _, file := range files {
+		if len(funcs[file]) == 0 {
+			denied = append(denied, file)
+			continue
+		}
+		denied = append(denied, fmt.Sprintf("%s (%s)", file, strings.Join(funcs[file], ", ")))
+	}
+
+	return fmt.Errorf("propagation would modify files outside the include/exclude patterns: %s", strings.Join(denied, "; "))
+}
+
+// compileGlobs compiles slash-separated glob patterns where * and ? do not cross directories and
+// ** matches any number of them, e.g. internal/legacy/** or **/*_gen.go.
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, pattern := range patterns {
+		var sb strings.Builder
+		sb.WriteString("^")
+		rest := strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		for rest != "" {
+			switch {
+			case strings.HasPrefix(rest, "**/"):
+				sb.WriteString("(?:.*/)?")
+				rest = rest[3:]
+			case strings.HasPrefix(rest, "**"):
+				sb.WriteString(".*")
+				rest = rest[2:]
+			case rest[0] == '*':
+				sb.WriteString("[^/]*")
+				rest = rest[1:]
+			case rest[0] == '?':
+				sb.WriteString("[^/]")
+				rest = rest[1:]
+			default:
+				sb.WriteString(regexp.QuoteMeta(rest[:1]))
+				rest = rest[1:]
+			}
+		}
+		sb.WriteString("$")
+		re, err := regexp.Compile(sb.String())
+		if err != nil {
+			return nil, fmt.Errorf("compiling %q: %w", pattern, err)
+		}
+		out = append(out, re)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,228 @@
+package goctx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Root context policies accepted by Options.MainCtx.
+const (
+	MainCtxBackground    = "background"
+	MainCtxTODO          = "todo"
+	MainCtxSignal        = "signal"
+	MainCtxTimeoutPrefix = "timeout:"
+)
+
+// mainCtxPolicy is a parsed Options.MainCtx.
+type mainCtxPolicy struct {
+	kind    string
+	timeout time.Duration
+}
+
+// parseMainCtx parses background (the default when empty), todo, signal or timeout:<duration>.
+func parseMainCtx(raw string) (mainCtxPolicy, error) {
+	switch raw = strings.TrimSpace(raw); {
+	case raw == "" || raw == MainCtxBackground:
+		return mainCtxPolicy{kind: MainCtxBackground}, nil
+	case raw == MainCtxTODO || raw == MainCtxSignal:
+		return mainCtxPolicy{kind: raw}, nil
+	case strings.HasPrefix(raw, MainCtxTimeoutPrefix):
+		d, err := time.ParseDuration(strings.TrimPrefix(raw, MainCtxTimeoutPrefix))
+		if err != nil || d <= 0 {
+			return mainCtxPolicy{}, fmt.Errorf("invalid main-ctx %q: want a positive duration after %s", raw, MainCtxTimeoutPrefix)
+		}
+
+		return mainCtxPolicy{kind: MainCtxTimeoutPrefix, timeout: d}, nil
+	}
+
+	return mainCtxPolicy{}, fmt.Errorf("invalid main-ctx %q, want one of background, todo, signal or timeout:<duration>", raw)
+}
+
+// stmts returns the statements creating the context variable name at the top of main, naming
+// the other variables and the packages they use as names says.
+func (p mainCtxPolicy) stmts(name string, names *mainNames) []ast.Stmt {
+	background := &ast.CallExpr{Fun: selector(names.pkg("context"), "Background")}
+	switch p.kind {
+	case MainCtxTODO:
+		return []ast.Stmt{defineCtx(name, nil, &ast.CallExpr{Fun: selector(names.pkg("context"), "TODO")})}
+	case MainCtxSignal:
+		// ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		call := &ast.CallExpr{
+			Fun:  selector(names.pkg("os/signal"), "NotifyContext"),
+			Args: []ast.Expr{background, selector(names.pkg("os"), "Interrupt"), selector(names.pkg("syscall"), "SIGTERM")},
+		}
+		stop := names.fresh("stop")
+
+		return []ast.Stmt{defineCtx(name, ast.NewIdent(stop), call), deferCall(stop)}
+	case MainCtxTimeoutPrefix:
+		// ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		call := &ast.CallExpr{
+			Fun:  selector(names.pkg("context"), "WithTimeout"),
+			Args: []ast.Expr{background, durationExpr(p.timeout, names.pkg("time"))},
+		}
+		cancel := names.fresh("cancel")
+
+		return []ast.Stmt{defineCtx(name, ast.NewIdent(cancel), call), deferCall(cancel)}
+	}
+
+	return []ast.Stmt{defineCtx(name, nil, background)}
+}
+
+// mainNames picks the identifiers of the statements inserted at the top of main: variable names
+// that clash with no identifier of main, its package or the imports of its file, and the names the
+// packages they use are imported under, suffixed with a number when taken.
+type mainNames struct {
+	// taken are the identifiers of fn.
+	taken map[string]bool
+	// declared are the names of the package scope, of the imports of the file and of the
+	// variables picked by fresh.
+	declared map[string]bool
+	// imported maps the import paths of the file to their names.
+	imported map[string]string
+	// added maps the imports to add to their names.
+	added map[string]string
+}
+
+// newMainNames collects the identifiers of fn, declared in file of pkg.
+func newMainNames(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl) *mainNames {
+	n := &mainNames{
+		taken:    make(map[string]bool),
+		declared: make(map[string]bool),
+		imported: make(map[string]string),
+		added:    make(map[string]string),
+	}
+	ast.Inspect(fn, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Ident); ok {
+			n.taken[id.Name] = true
+		}
+
+		return true
+	})
+	if pkg.Types != nil {
+		for _, name := range pkg.Types.Scope().Names() {
+			n.declared[name] = true
+		}
+	}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := defaultImportName(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		n.declared[name] = true
+		if name != "_" && name != "." {
+			n.imported[path] = name
+		}
+	}
+
+	return n
+}
+
+// fresh returns a variable name for main: base, or base suffixed with a number when taken.
+func (n *mainNames) fresh(base string) string {
+	name := base
+	for i := 2; n.taken[name] || n.declared[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	n.taken[name], n.declared[name] = true, true
+
+	return name
+}
+
+// pkg returns the name to qualify the members of the package at path with: the name the file
+// imports it under, or the name of a new import. The statements are inserted before any local of
+// main, so only the package scope and the other imports can clash with it.
+func (n *mainNames) pkg(path string) string {
+	if name, ok := n.imported[path]; ok {
+		return name
+	}
+	base := defaultImportName(path)
+	name := base
+	for i := 2; n.declared[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	n.declared[name] = true
+	n.imported[path] = name
+	n.added[path] = name
+
+	return name
+}
+
+// defaultImportName is the name of the package at path when imported without one, assuming it
+// is its last element, as for the standard library.
+func defaultImportName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// addImports adds the imports pkg needed to file, naming those imported under another name than
+// their package's.
+func (n *mainNames) addImports(fset *token.FileSet, file *ast.File) {
+	for path, name := range n.added {
+		if name == defaultImportName(path) {
+			ensureImport(fset, file, path)
+		} else {
+			astutil.AddNamedImport(fset, file, name, path)
+		}
+	}
+}
+
+// defineCtx builds name := rhs, or name, second := rhs.
+func defineCtx(name string, second *ast.Ident, rhs ast.Expr) *ast.AssignStmt {
+	lhs := []ast.Expr{ast.NewIdent(name)}
+	if second != nil {
+		lhs = append(lhs, second)
+	}
+
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: []ast.Expr{rhs}}
+}
+
+func deferCall(name string) *ast.DeferStmt {
+	return &ast.DeferStmt{Call: &ast.CallExpr{Fun: ast.NewIdent(name)}}
+}
+
+func selector(x, sel string) *ast.SelectorExpr {
+	return &ast.SelectorExpr{X: ast.NewIdent(x), Sel: ast.NewIdent(sel)}
+}
+
+// durationExpr renders d in the largest time unit dividing it, e.g. 90*time.Second, with the time
+// package named timePkg.
+func durationExpr(d time.Duration, timePkg string) ast.Expr {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"Hour", time.Hour},
+		{"Minute", time.Minute},
+		{"Second", time.Second},
+		{"Millisecond", time.Millisecond},
+		{"Microsecond", time.Microsecond},
+		{"Nanosecond", time.Nanosecond},
+	}
+	for _, u := range units {
+		if d%u.size != 0 {
+			continue
+		}
+		unit := selector(timePkg, u.name)
+		if d == u.size {
+			return unit
+		}
+
+		return &ast.BinaryExpr{
+			X:  &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(int64(d/u.size), 10)},
+			Op: token.MUL,
+			Y:  unit,
+		}
+	}
+
+	return nil // unreachable: every duration is a multiple of a nanosecond
+}
@@ -0,0 +1,43 @@
+package goctx
+
+import (
+	"go/token"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMainCtx(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]mainCtxPolicy{
+		"":              {kind: MainCtxBackground},
+		"background":    {kind: MainCtxBackground},
+		"todo":          {kind: MainCtxTODO},
+		"signal":        {kind: MainCtxSignal},
+		"timeout:1m30s": {kind: MainCtxTimeoutPrefix, timeout: 90 * time.Second},
+	} {
+		got, err := parseMainCtx(raw)
+		require.NoError(t, err, raw)
+		require.Equal(t, want, got, raw)
+	}
+	for _, raw := range []string{"forever", "timeout:", "timeout:-1s"} {
+		_, err := parseMainCtx(raw)
+		require.ErrorContains(t, err, "invalid main-ctx", raw)
+	}
+}
+
+func TestDurationExpr(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	for d, want := range map[time.Duration]string{
+		time.Hour:               "time.Hour",
+		90 * time.Second:        "90 * time.Second",
+		2 * time.Hour:           "2 * time.Hour",
+		1500 * time.Millisecond: "1500 * time.Millisecond",
+	} {
+		require.Equal(t, want, nodeSource(fset, durationExpr(d, "time")))
+	}
+}
@@ -1,12 +1,11 @@
 package goctx
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"go/ast"
-	"go/format"
+	"go/parser"
 	"go/token"
 	"go/types"
 	"log/slog"
@@ -19,101 +18,336 @@ import (
 
 // Options configures the goctx run.
 // WorkDir should point at the module root (or any subdir); we will load ./...
-// Target syntax: path/to/file.go:FuncName[:N]
+// Target syntax: path/to/file.go:FuncName[:N], path/to/file.go:Type.Method[:N],
+// path/to/file.go:(*Type).Method[:N], path/to/file.go:#L42, path/to/file.go:42:7,
+// example.com/pkg.Func or example.com/pkg.(*Type).Method.
 // StopAt optional syntax: same as Target.
 // HTML: if true, stop when reaching http.HandlerFunc boundary and derive ctx from req.Context().
 type Options struct {
-	Target  string
+	Target string
+	// Targets are additional targets handled in the same run as Target: the module is loaded
+	// once and the call graph is walked from all of them together.
+	Targets []string
 	StopAt  string
+	// StopAts are additional stop-at boundaries. Besides the Target syntax, each entry may be a
+	// package pattern (./internal/api, ./internal/api/..., example.com/app/api/...) or a regular
+	// expression over fully qualified function names prefixed with "re:".
+	StopAts []string
 	HTML    bool
 	WorkDir string
 	// Tags are passed through to the Go loader as -tags=... build flags, controlling
 	// which files behind build constraints are visible to the tool.
 	Tags string
+	// CtxName is the identifier used for the context parameters and variables goctx introduces,
+	// including by the suggested fixes of Check and the analyzer. Defaults to ctx.
+	CtxName string
+	// CtxType is the type of the context parameters goctx introduces, qualified by import path,
+	// e.g. example.com/app/appctx.Ctx or *example.com/app/appctx.Ctx. It must implement
+	// context.Context. Values of this type and of context.Context both count as a context in
+	// scope. Defaults to context.Context.
+	CtxType string
+	// ContextSources are values that supply a context where there is none: a function with a
+	// parameter or receiver field of a source type passes the context derived from it to its
+	// callees instead of gaining a ctx parameter. See DefaultContextSources.
+	ContextSources []ContextSource
+	// Param threads a value of another type instead of a context (see ParamSpec). CtxName,
+	// CtxType, MainCtx and CompatWrappers do not apply then.
+	Param *ParamSpec
+	// Unstore names a struct field holding a context, as Type.field or, when the type name is
+	// ambiguous, example.com/pkg.Type.field. The field and the values stored in it are removed,
+	// functions reading it get a ctx parameter instead, constructors drop the ctx parameter they
+	// only stored, and callers are updated as for targets. Targets are optional then.
+	Unstore string
+	// UpgradeStdlib rewrites calls such as http.NewRequest or (*sql.DB).Query in the functions
+	// propagation modifies to their context-aware variants (see UpgradeStdlib).
+	UpgradeStdlib bool
+	// StdlibUpgrades are upgrades used by UpgradeStdlib in addition to DefaultStdlibUpgrades,
+	// taking precedence over them.
+	StdlibUpgrades []StdlibUpgrade
+	// ContextVariants rewrites calls such as Fetch(id) in the functions propagation modifies to
+	// FetchContext(ctx, id), when a package of the module declares both, FetchContext taking a
+	// context followed by the parameters of Fetch and returning the same results.
+	ContextVariants bool
+	// UpgradeSlog rewrites slog.Info, (*slog.Logger).Error and the other level functions in the
+	// functions propagation modifies to their Context variants (see UpgradeSlog).
+	UpgradeSlog bool
+	// PropagateToLoggers makes every function that calls a slog level function without a context
+	// in scope a target, so that it can log with the context of its callers.
+	PropagateToLoggers bool
+	// PreserveExported stops propagation at exported functions and methods of non-internal
+	// library packages: instead of changing their signature, the call inside them is passed
+	// PreserveExportedExpr and the spot is reported as APIDebt in the plan.
+	PreserveExported bool
+	// PreserveExportedExpr is the context expression passed at preserved call sites.
+	// Defaults to context.TODO().
+	PreserveExportedExpr string
+	// CompatWrappers keeps exported functions callable with their old signature: an exported Foo
+	// that would gain a context parameter becomes FooContext, and Foo is kept as a deprecated
+	// wrapper passing context.Background(). Callers with a context in scope switch to FooContext.
+	CompatWrappers bool
+	// BoundaryRules make functions with a parameter of a given type boundaries, deriving ctx from
+	// that parameter (see LoadBoundaryRules).
+	BoundaryRules []BoundaryRule
+	// CLI treats cobra Run/RunE/PreRunE functions (ctx from cmd.Context()), urfave/cli v2 actions
+	// (ctx from c.Context) and urfave/cli v3 actions (which already receive ctx) as boundaries,
+	// including function literals.
+	CLI bool
+	// Include and Exclude are slash-separated glob patterns, relative to the module root, of the
+	// files goctx may modify: ** matches any number of directories, e.g. internal/legacy/** or
+	// **/*_gen.go. Plan fails when propagation would have to modify a file not included or
+	// excluded. Empty Include means every file.
+	Include []string
+	Exclude []string
+	// MainCtx is the root context created in main when propagation reaches it: background (the
+	// default), todo, signal (signal.NotifyContext on os.Interrupt and SIGTERM) or
+	// timeout:<duration> (context.WithTimeout). The cancel function is deferred.
+	MainCtx string
+	// Web names web frameworks whose handlers are boundaries, like net/http handlers with HTML:
+	// gin (ctx from c.Request.Context()), echo (c.Request().Context()), fiber (c.UserContext())
+	// and chi (plain net/http handlers).
+	Web []string
+	// MaxDepth limits propagation to this many caller levels above the targets; callers further
+	// up pass context.TODO() instead and are reported in ChangePlan.Truncated. 0 means unlimited.
+	MaxDepth int
+	// MaxChanges makes Plan (and so Run) fail before anything is written when the plan would
+	// modify more than this many functions. 0 means unlimited.
+	MaxChanges int
 }
 
-// Run performs the goctx according to Options.
-func Run(_ context.Context, opts Options) error {
-	slog.Debug("run start",
-		slog.String("target", opts.Target),
-		slog.String("stopAt", opts.StopAt),
+// targetSpecs returns Target followed by Targets, skipping blank entries.
+func (o Options) targetSpecs() []string {
+	var out []string
+	for _, t := range append([]string{o.Target}, o.Targets...) {
+		if strings.TrimSpace(t) != "" {
+			out = append(out, t)
+		}
+	}
+
+	return out
+}
+
+// Run performs the goctx according to Options: it computes a plan and applies it to the files
+// on disk.
+func Run(ctx context.Context, opts Options) error {
+	plan, err := Plan(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if err := plan.Apply(ctx, OSFS{}); err != nil {
+		slog.Debug("apply plan error", slog.Any("error", err))
+		return fmt.Errorf("writing modified files: %w", err)
+	}
+	slog.Debug("run done")
+
+	return nil
+}
+
+// Plan computes the edits goctx would make according to Options without writing any file.
+// Cancelling ctx aborts loading and propagation; the returned error then wraps ctx.Err().
+func Plan(ctx context.Context, opts Options) (*ChangePlan, error) {
+	slog.Debug("plan start",
+		slog.Any("targets", opts.targetSpecs()),
+		slog.Any("stopAt", opts.stopAtSpecs()),
 		slog.Bool("html", opts.HTML),
 		slog.String("workDir", firstNonEmpty(opts.WorkDir, ".")),
 	)
-	if opts.Target == "" {
-		return errors.New("missing target argument")
+	if len(opts.targetSpecs()) == 0 && opts.Unstore == "" && !opts.PropagateToLoggers {
+		return nil, errors.New("missing target argument")
+	}
+	if err := validatePlanOptions(opts); err != nil {
+		return nil, err
 	}
 
 	// Load all packages in the workspace
-	pkgs, err := loadAllPackages(firstNonEmpty(opts.WorkDir, "."), opts.Tags)
+	pkgs, err := loadAllPackages(ctx, firstNonEmpty(opts.WorkDir, "."), opts.Tags)
 	if err != nil {
 		slog.Debug("loadAllPackages error", slog.String("workDir", firstNonEmpty(opts.WorkDir, ".")), slog.Any("error", err))
-		return err
+		return nil, err
 	}
 	slog.Debug("packages loaded", slog.Int("count", len(pkgs)))
 
-	// Parse target and optional stopAt
-	tgtSpec, err := parseTargetSpec(opts.Target)
-	if err != nil {
-		return fmt.Errorf("parsing target: %w", err)
+	return planPackages(ctx, opts, pkgs, nil)
+}
+
+// validatePlanOptions checks the options of Plan that can be checked without loading packages.
+func validatePlanOptions(opts Options) error {
+	if err := validateBoundaryRules(opts.BoundaryRules); err != nil {
+		return err
 	}
-	slog.Debug("target parsed", slog.String("file", tgtSpec.File), slog.String("func", tgtSpec.FuncName), slog.Int("line", tgtSpec.LineNumber))
-	stopSpec, err := parseStopSpec(opts.StopAt)
-	if err != nil {
-		if _, ok := errors.AsType[noStopSpecError](err); !ok { //nolint:errcheck // False positive.
-			slog.Debug("stopAt parse error", slog.String("stopAt", opts.StopAt), slog.Any("error", err))
-			return err
+	if err := validateWeb(opts.Web); err != nil {
+		return err
+	}
+	if _, err := parseMainCtx(opts.MainCtx); err != nil {
+		return err
+	}
+	if err := validateContextSources(opts.ContextSources); err != nil {
+		return err
+	}
+	if opts.Param != nil && opts.CompatWrappers {
+		return errors.New("compat wrappers apply to context parameters only")
+	}
+	if opts.Param != nil && (opts.UpgradeStdlib || opts.UpgradeSlog || opts.PropagateToLoggers || opts.ContextVariants) {
+		return errors.New("stdlib and slog upgrades apply to context parameters only")
+	}
+	if err := validateStdlibUpgrades(opts.StdlibUpgrades); err != nil {
+		return err
+	}
+	if opts.PreserveExportedExpr != "" {
+		if _, err := parser.ParseExpr(opts.PreserveExportedExpr); err != nil {
+			return fmt.Errorf("parsing preserve-exported expression %q: %w", opts.PreserveExportedExpr, err)
 		}
 	}
-	if stopSpec != nil {
-		slog.Debug("stopAt parsed", slog.String("file", stopSpec.File), slog.String("func", stopSpec.FuncName), slog.Int("line", stopSpec.LineNumber))
-	} else {
-		slog.Debug("stopAt not provided")
+
+	return nil
+}
+
+// planPackages computes the plan of Plan over the loaded packages. With
+// Options.PropagateToLoggers, the functions of the packages in loggers (all when nil) that log
+// without a context in scope are targets too.
+func planPackages(ctx context.Context, opts Options, pkgs []*packages.Package, loggers map[string]bool) (*ChangePlan, error) {
+	filter, err := newFileFilter(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Find the package and file for the target
-	res, err := resolveTarget(pkgs, tgtSpec)
+	// Parse optional stop-at boundaries
+	stops, err := parseStopSpecs(opts)
 	if err != nil {
-		return fmt.Errorf("resolving target: %w", err)
+		slog.Debug("stopAt parse error", slog.Any("stopAt", opts.stopAtSpecs()), slog.Any("error", err))
+		return nil, err
+	}
+	slog.Debug("stopAt parsed", slog.Int("count", len(stops.specs)))
+
+	cp, err := resolveCtxParam(opts, pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse and resolve every target; the same declaration named twice is handled once.
+	var resolved []*targetResolution
+	seenDecls := make(map[*ast.FuncDecl]bool)
+	for _, target := range opts.targetSpecs() {
+		tgtSpec, err := parseTargetSpec(target)
+		if err != nil {
+			return nil, fmt.Errorf("parsing target %s: %w", target, err)
+		}
+		res, err := resolveTarget(pkgs, tgtSpec)
+		if err != nil {
+			return nil, fmt.Errorf("resolving target %s: %w", target, err)
+		}
+		slog.Debug("target resolved",
+			slog.String("file", res.Fset.File(res.Decl.Pos()).Name()),
+			slog.String("func", res.Decl.Name.Name),
+		)
+		if !seenDecls[res.Decl] {
+			seenDecls[res.Decl] = true
+			resolved = append(resolved, res)
+		}
 	}
-	slog.Debug("target resolved",
-		slog.String("file", res.Fset.File(res.Decl.Pos()).Name()),
-		slog.String("func", res.Decl.Name.Name),
-	)
 
 	modifiedFiles := make(map[string]bool)
+	changes := newChangeLog()
 
-	// Decide if target already has a usable context.Context parameter (reuse case)
-	reuseExistingCtxInTarget := functionHasContextParam(res.Decl, res.Info)
-	slog.Debug("target context param check", slog.Bool("hasContextParam", reuseExistingCtxInTarget))
+	// Functions that log without a context are targets, unless they are boundaries deriving one.
+	if opts.PropagateToLoggers {
+		for _, res := range loggingFuncs(pkgs, loggers, filter, cp) {
+			if seenDecls[res.Decl] {
+				continue
+			}
+			seenDecls[res.Decl] = true
+			stopHere, reason, err := shouldStopAt(res.Decl, res.Pkg, opts, stops)
+			if err != nil {
+				return nil, fmt.Errorf("checking stop boundary: %w", err)
+			}
+			if !stopHere {
+				resolved = append(resolved, res)
+				continue
+			}
+			derived, err := ensureCtxAvailableAtBoundary(res.Pkg, res.FileAST, res.Decl, reason, opts, cp)
+			if err != nil {
+				return nil, fmt.Errorf("ensuring ctx at stop boundary: %w", err)
+			}
+			if derived {
+				changes.note(res.Decl, "derive %s at %s boundary", cp.name, reason)
+				markFileModified(modifiedFiles, res.Fset, res.FileAST)
+			}
+		}
+	}
+
+	// Targets that already have a usable context.Context parameter are reused as is: their callers
+	// already pass a context, so only the others seed the traversal.
+	var start []types.Object
+	for _, res := range resolved {
+		reuseExistingCtxInTarget := functionHasContextParam(res.Decl, res.Info, cp)
+		slog.Debug("target context param check", slog.String("func", res.Decl.Name.Name), slog.Bool("hasContextParam", reuseExistingCtxInTarget))
 
-	// Ensure target function has ctx param (do not rename blank yet)
-	ensureTargetHasCtx(res, modifiedFiles)
+		if !reuseExistingCtxInTarget && wantsCompatWrapper(opts, res.Decl) {
+			if err := convertToCompat(res.Pkg, res.FileAST, res.Decl, changes); err != nil {
+				return nil, fmt.Errorf("generating compat wrapper: %w", err)
+			}
+		}
 
-	// Traverse callers recursively and propagate ctx as needed, unless the target already has a context parameter
-	var sawAnyCall bool
-	if !reuseExistingCtxInTarget {
-		slog.Debug("traverse and propagate start")
-		if err := traverseAndPropagate(pkgs, res.Obj, opts, stopSpec, modifiedFiles, &sawAnyCall); err != nil {
+		// Ensure target function has ctx param (do not rename blank yet)
+		ensureTargetHasCtx(res, cp, modifiedFiles, changes)
+		if !reuseExistingCtxInTarget {
+			start = append(start, res.Obj)
+		}
+	}
+
+	// Readers of an unstored field seed the traversal like targets.
+	tidy := func() {}
+	if opts.Unstore != "" {
+		readers, tidyImports, err := unstoreField(pkgs, opts.Unstore, cp, modifiedFiles, changes)
+		if err != nil {
+			return nil, fmt.Errorf("unstoring %s: %w", opts.Unstore, err)
+		}
+		start, tidy = append(start, readers...), tidyImports
+	}
+
+	// Traverse callers recursively and propagate ctx as needed
+	called := make(map[types.Object]bool)
+	if len(start) > 0 {
+		slog.Debug("traverse and propagate start", slog.Int("targets", len(start)))
+		if err := traverseAndPropagate(ctx, pkgs, start, opts, cp, stops, modifiedFiles, changes, called); err != nil {
 			slog.Debug("traverse and propagate error", slog.Any("error", err))
-			return err
+			return nil, err
 		}
-		slog.Debug("traverse and propagate done", slog.Bool("sawAnyCall", sawAnyCall))
+		slog.Debug("traverse and propagate done", slog.Int("calledFuncs", len(called)))
 	}
 
-	// If the target has a blank-named context param and there are no callers,
+	// If a target has a blank-named context param and there are no callers,
 	// rename it to ctx (covers the dedicated rename test case) without affecting
 	// the case where callers exist and we should preserve '_'.
-	maybeRenameBlankCtxInTarget(res, modifiedFiles, sawAnyCall)
+	for _, res := range resolved {
+		maybeRenameBlankCtxInTarget(res, cp, modifiedFiles, changes, called[res.Obj])
+	}
+	tidy()
+	convertErrGroups(pkgs, cp, modifiedFiles, changes)
+	if opts.UpgradeStdlib || opts.UpgradeSlog || opts.ContextVariants {
+		if err := upgradeModifiedFuncs(pkgs, opts, cp, modifiedFiles, changes); err != nil {
+			return nil, err
+		}
+	}
 
-	// Write back modified files
-	if err := writeModified(pkgs); err != nil {
-		slog.Debug("write modified error", slog.Any("error", err))
-		return fmt.Errorf("writing modified files: %w", err)
+	if err := interrupted(ctx, "planning edits"); err != nil {
+		return nil, err
 	}
-	slog.Debug("run done")
+	plan, err := buildPlan(pkgs, modifiedFiles, changes)
+	if err != nil {
+		slog.Debug("build plan error", slog.Any("error", err))
+		return nil, fmt.Errorf("planning edits: %w", err)
+	}
+	slog.Debug("plan done", slog.Int("edits", len(plan.Edits)))
 
-	return nil
+	if err := checkFilteredFiles(plan, filter); err != nil {
+		return nil, err
+	}
+	if funcs := plan.Functions(); opts.MaxChanges > 0 && len(funcs) > opts.MaxChanges {
+		return nil, fmt.Errorf("plan would modify %d functions, more than the maximum of %d: %s",
+			len(funcs), opts.MaxChanges, strings.Join(funcs, ", "))
+	}
+
+	return plan, nil
 }
 
 // loadAllPackages loads all packages in the current Go module that contains dir.
@@ -121,7 +355,7 @@ func Run(_ context.Context, opts Options) error {
 // directories that belong to the same module when dir is a subdirectory.
 // Now we discover the module root (by locating the nearest go.mod upwards from dir)
 // and set packages.Config.Dir to that root, ensuring the entire module is loaded.
-func loadAllPackages(dir string, tags string) ([]*packages.Package, error) {
+func loadAllPackages(ctx context.Context, dir string, tags string) ([]*packages.Package, error) {
 	slog.Debug("loading packages", slog.String("dir", dir))
 
 	moduleRoot := findModuleRoot(dir)
@@ -140,8 +374,9 @@ func loadAllPackages(dir string, tags string) ([]*packages.Package, error) {
 			packages.NeedTypes |
 			packages.NeedTypesInfo |
 			packages.NeedSyntax,
-		Dir:   loadDir,
-		Tests: true, // include _test.go files and test variants
+		Context: ctx,
+		Dir:     loadDir,
+		Tests:   true, // include _test.go files and test variants
 	}
 	if strings.TrimSpace(tags) != "" {
 		// Pass through build tags to include/exclude files under build constraints
@@ -149,6 +384,9 @@ func loadAllPackages(dir string, tags string) ([]*packages.Package, error) {
 		slog.Debug("applied build tags", slog.String("tags", tags))
 	}
 	pkgs, err := packages.Load(cfg, "./...")
+	if err := interrupted(ctx, "loading packages"); err != nil {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("loading packages: %w", err)
 	}
@@ -161,6 +399,16 @@ func loadAllPackages(dir string, tags string) ([]*packages.Package, error) {
 	return pkgs, nil
 }
 
+// interrupted returns ctx.Err() wrapped with the phase being run when ctx is done, and nil
+// otherwise.
+func interrupted(ctx context.Context, phase string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s interrupted: %w", phase, err)
+	}
+
+	return nil
+}
+
 // findModuleRoot walks up from startDir to find the nearest directory containing a go.mod.
 // Returns the directory path if found; otherwise returns empty string.
 func findModuleRoot(startDir string) string {
@@ -190,51 +438,39 @@ func findModuleRoot(startDir string) string {
 
 // maybeRenameBlankCtxInTarget renames a blank-named context parameter to ctx for the target function
 // only when no callers were found during traversal (standalone function case).
-func maybeRenameBlankCtxInTarget(res *targetResolution, modifiedFiles map[string]bool, sawAnyCall bool) {
+func maybeRenameBlankCtxInTarget(res *targetResolution, cp ctxParam, modifiedFiles map[string]bool, changes *changeLog, sawAnyCall bool) {
 	if res == nil || res.Decl == nil || res.FileAST == nil || res.Fset == nil {
 		return
 	}
 	if sawAnyCall {
 		return // there are callers; preserve '_'
 	}
-	if ensureFuncHasCtxParam(res.Fset, res.FileAST, res.Decl, res.Info, true) {
+	if ensureFuncHasCtxParam(res.Fset, res.FileAST, res.Decl, res.Info, cp, true) {
+		changes.note(res.Decl, "rename blank %s parameter of target to %s", cp.noun(), cp.name)
 		markFileModified(modifiedFiles, res.Fset, res.FileAST)
 	}
 }
 
-// noStopSpecError is a sentinel error indicating the user did not provide a stop-at spec.
-// It allows callers to distinguish between "no spec provided" and real errors, avoiding nil-nil returns.
-type noStopSpecError struct{}
-
-func (noStopSpecError) Error() string { return "no stop-at spec provided" }
-
-// parseStopSpec parses the optional stop-at spec.
-// When no spec is provided (empty/whitespace), it returns (nil, noStopSpecError{}).
-func parseStopSpec(stopAt string) (*targetSpec, error) {
-	if strings.TrimSpace(stopAt) == "" {
-		return nil, noStopSpecError{}
-	}
-	ss, err := parseTargetSpec(stopAt)
-	if err != nil {
-		return nil, fmt.Errorf("parsing stop-at: %w", err)
-	}
-
-	return &ss, nil
-}
-
 // ensureTargetHasCtx guarantees the target function has a ctx parameter and marks file modified.
-func ensureTargetHasCtx(res *targetResolution, modifiedFiles map[string]bool) {
-	if ensureFuncHasCtxParam(res.Fset, res.FileAST, res.Decl, res.Info, false) {
+func ensureTargetHasCtx(res *targetResolution, cp ctxParam, modifiedFiles map[string]bool, changes *changeLog) {
+	if ensureFuncHasCtxParam(res.Fset, res.FileAST, res.Decl, res.Info, cp, false) {
+		assignLocalCtx(res.Decl, res.Info, cp)
+		changes.note(res.Decl, "add %s parameter to target", cp.noun())
 		modifiedFiles[res.FileAST.Name.Name] = true // marker by pkg name; we'll use filenames later
 		markFileModified(modifiedFiles, res.Fset, res.FileAST)
 	}
 }
 
-// traverseAndPropagate walks callers recursively from start and ensures ctx propagation.
-func traverseAndPropagate(pkgs []*packages.Package, start types.Object, opts Options, stopSpec *targetSpec, modifiedFiles map[string]bool, sawAnyCall *bool) error {
+// traverseAndPropagate walks callers recursively from the start functions and ensures ctx
+// propagation. Every function found to have at least one call site is recorded in called.
+func traverseAndPropagate(ctx context.Context, pkgs []*packages.Package, start []types.Object, opts Options, cp ctxParam, stops *stopSet, modifiedFiles map[string]bool, changes *changeLog, called map[types.Object]bool) error {
 	visited := make(map[types.Object]bool)
-	queue := []types.Object{start}
+	depths := make(map[types.Object]int) // caller level above the targets, which are at 0
+	queue := append([]types.Object(nil), start...)
 	for len(queue) > 0 {
+		if err := interrupted(ctx, "propagating context"); err != nil {
+			return err
+		}
 		curr := queue[0]
 		queue = queue[1:]
 		if visited[curr] {
@@ -254,10 +490,13 @@ func traverseAndPropagate(pkgs []*packages.Package, start types.Object, opts Opt
 					fileAST:       fileAST,
 					curr:          curr,
 					opts:          opts,
-					stopSpec:      stopSpec,
+					cp:            cp,
+					stops:         stops,
 					modifiedFiles: modifiedFiles,
+					changes:       changes,
 					queue:         &queue,
-					sawAnyCall:    sawAnyCall,
+					depths:        depths,
+					called:        called,
 				}
 				if err := processCallSites(params); err != nil {
 					return err
@@ -276,10 +515,13 @@ type processCallSitesParams struct {
 	fileAST       *ast.File
 	curr          types.Object
 	opts          Options
-	stopSpec      *targetSpec
+	cp            ctxParam
+	stops         *stopSet
 	modifiedFiles map[string]bool
+	changes       *changeLog
 	queue         *[]types.Object
-	sawAnyCall    *bool
+	depths        map[types.Object]int
+	called        map[types.Object]bool
 }
 
 // resolveCalled attempts to resolve the types.Object being invoked by a call expression's Fun.
@@ -454,8 +696,8 @@ func processCallSites(params processCallSitesParams) error {
 			return true
 		}
 		// Found a call. Mark that at least one call site exists.
-		if params.sawAnyCall != nil {
-			*params.sawAnyCall = true
+		if params.called != nil {
+			params.called[params.curr] = true
 		}
 		// Find enclosing function decl.
 		enc := enclosingFuncDecl(params.fileAST, call)
@@ -463,7 +705,35 @@ func processCallSites(params processCallSitesParams) error {
 			return true
 		}
 
-		stopHere, stopReason, err := shouldStopAt(enc, params.pkg, params.opts, params.stopSpec)
+		// Compat functions keep their signature through the generated wrapper: only callers with a
+		// ctx in scope switch to the context-aware sibling, and propagation ends here.
+		if params.changes.isCompat(params.curr) {
+			if hasCtxInScope(enc, params.pkg, params.cp) {
+				redirectCallToCompat(call, params.curr.Name())
+				ensureCallHasCtxArg(params.pkg, call, getCtxIdentInScope(enc, params.pkg, params.cp), params.cp)
+				params.changes.note(enc, "call %s%s with ctx", params.curr.Name(), SuffixContext)
+				markCurrentFileModified(params)
+			}
+
+			return true
+		}
+
+		// Closures handed to a framework, such as cobra's RunE, are boundaries of their own: ctx is
+		// derived inside the closure instead of being threaded into the enclosing function.
+		if lit, reason := boundaryFuncLit(enc, call, params.pkg, params.opts); lit != nil {
+			if _, err := ensureCtxAvailableAtBoundary(params.pkg, params.fileAST, lit, reason, params.opts, params.cp); err != nil {
+				inspectErr = fmt.Errorf("ensuring ctx at stop boundary: %w", err)
+				return false
+			}
+			ensureCallHasCtxArg(params.pkg, call, getCtxIdentInScope(lit, params.pkg, params.cp), params.cp)
+			params.changes.note(enc, "derive %s at %s boundary in function literal", params.cp.name, reason)
+			params.changes.note(enc, "pass %s to %s", params.cp.name, params.curr.Name())
+			markCurrentFileModified(params)
+
+			return true
+		}
+
+		stopHere, stopReason, err := shouldStopAt(enc, params.pkg, params.opts, params.stops)
 		if err != nil {
 			inspectErr = fmt.Errorf("checking stop boundary: %w", err)
 			return false
@@ -471,32 +741,106 @@ func processCallSites(params processCallSitesParams) error {
 
 		if stopHere {
 			// At stop boundary: ensure a ctx exists, derive if necessary (main/http) and always pass ctx to call
-			if _, err := ensureCtxAvailableAtBoundary(params.pkg, params.fileAST, enc, stopReason); err != nil {
+			if _, err := ensureCtxAvailableAtBoundary(params.pkg, params.fileAST, enc, stopReason, params.opts, params.cp); err != nil {
 				inspectErr = fmt.Errorf("ensuring ctx at stop boundary: %w", err)
 				return false
 			}
-			ctxName := getCtxIdentInScope(enc, params.pkg)
-			ensureCallHasCtxArg(params.pkg, call, ctxName)
+			ctxName := getCtxIdentInScope(enc, params.pkg, params.cp)
+			ensureCallHasCtxArg(params.pkg, call, ctxName, params.cp)
+			params.changes.note(enc, "derive %s at %s boundary", params.cp.name, stopReason)
+			params.changes.note(enc, "pass %s to %s", params.cp.name, params.curr.Name())
 			markCurrentFileModified(params)
 
 			return true
 		}
 
 		// If ctx in scope, just pass; do not enqueue since callers already pass their own context
-		if hasCtxInScope(enc, params.pkg) {
-			ctxName := getCtxIdentInScope(enc, params.pkg)
-			ensureCallHasCtxArg(params.pkg, call, ctxName)
+		if hasCtxInScope(enc, params.pkg, params.cp) {
+			ctxName := getCtxIdentInScope(enc, params.pkg, params.cp)
+			ensureCallHasCtxArg(params.pkg, call, ctxName, params.cp)
+			params.changes.note(enc, "pass %s to %s", params.cp.name, params.curr.Name())
 			markCurrentFileModified(params)
 
 			return true
 		}
 
+		// A value in scope that carries a context, such as r *http.Request, supplies one without
+		// changing the signature.
+		if !params.cp.generic {
+			expr, err := ctxSourceInScope(enc, params.pkg, params.opts.ContextSources)
+			if err != nil {
+				inspectErr = fmt.Errorf("deriving ctx in %s: %w", funcKeyOfDecl(params.pkg, enc), err)
+				return false
+			}
+			if expr != "" {
+				ensureCallHasCtxArg(params.pkg, call, expr, params.cp)
+				params.changes.note(enc, "pass %s to %s", expr, params.curr.Name())
+				markCurrentFileModified(params)
+
+				return true
+			}
+		}
+
 		// Determine whether the enclosing function already has a context parameter (possibly named "_")
-		hadCtxParam := functionHasContextParam(enc, params.pkg.TypesInfo)
+		hadCtxParam := functionHasContextParam(enc, params.pkg.TypesInfo, params.cp)
+
+		// Keep public signatures of library packages intact: pass a placeholder context instead.
+		if !hadCtxParam && params.opts.PreserveExported && isPublicAPI(enc, params.pkg) {
+			expr, err := params.cp.placeholder(params.opts.PreserveExportedExpr)
+			if err != nil {
+				inspectErr = fmt.Errorf("preserving %s: %w", funcKeyOfDecl(params.pkg, enc), err)
+				return false
+			}
+			passPlaceholderCtx(params, call, expr)
+			params.changes.note(enc, "pass %s to %s to preserve the exported signature", expr, params.curr.Name())
+			params.changes.addDebt(APIDebt{
+				Pos:      params.pkg.Fset.Position(call.Pos()),
+				Function: funcKeyOfDecl(params.pkg, enc),
+				Callee:   params.curr.Name(),
+				Expr:     expr,
+			})
+
+			return true
+		}
+
+		// Cut the walk at the maximum depth: enc would sit one level above it.
+		depth := params.depths[params.curr] + 1
+		if !hadCtxParam && params.opts.MaxDepth > 0 && depth > params.opts.MaxDepth {
+			expr, err := params.cp.placeholder("")
+			if err != nil {
+				inspectErr = fmt.Errorf("max depth reached in %s: %w", funcKeyOfDecl(params.pkg, enc), err)
+				return false
+			}
+			passPlaceholderCtx(params, call, expr)
+			params.changes.note(enc, "pass %s to %s at max depth %d", expr, params.curr.Name(), params.opts.MaxDepth)
+			params.changes.addTruncation(Truncation{
+				Pos:      params.pkg.Fset.Position(call.Pos()),
+				Function: funcKeyOfDecl(params.pkg, enc),
+				Callee:   params.curr.Name(),
+				Expr:     expr,
+				Depth:    depth,
+			})
+
+			return true
+		}
+
+		if !hadCtxParam && wantsCompatWrapper(params.opts, enc) {
+			if err := convertToCompat(params.pkg, params.fileAST, enc, params.changes); err != nil {
+				inspectErr = fmt.Errorf("generating compat wrapper: %w", err)
+				return false
+			}
+		}
+
 		// Ensure a usable ctx param exists (adds one if missing, or renames '_' to 'ctx')
-		ensureFuncHasCtxParam(params.pkg.Fset, params.fileAST, enc, params.pkg.TypesInfo, true)
-		ctxName := getCtxIdentInScope(enc, params.pkg)
-		ensureCallHasCtxArg(params.pkg, call, ctxName)
+		ensureFuncHasCtxParam(params.pkg.Fset, params.fileAST, enc, params.pkg.TypesInfo, params.cp, true)
+		ctxName := getCtxIdentInScope(enc, params.pkg, params.cp)
+		ensureCallHasCtxArg(params.pkg, call, ctxName, params.cp)
+		if hadCtxParam {
+			params.changes.note(enc, "reuse existing %s parameter", params.cp.noun())
+		} else {
+			params.changes.note(enc, "add %s parameter (needed by %s)", params.cp.noun(), params.curr.Name())
+		}
+		params.changes.note(enc, "pass %s to %s", params.cp.name, params.curr.Name())
 		// Mark file modified (either signature or call site changed)
 		markCurrentFileModified(params)
 
@@ -504,6 +848,9 @@ func processCallSites(params processCallSitesParams) error {
 		// do not traverse further; callers of this function already pass their context argument.
 		if !hadCtxParam {
 			if def := params.pkg.TypesInfo.Defs[enc.Name]; def != nil {
+				if _, seen := params.depths[def]; !seen {
+					params.depths[def] = depth
+				}
 				*params.queue = append(*params.queue, def)
 			}
 		}
@@ -514,6 +861,13 @@ func processCallSites(params processCallSitesParams) error {
 	return inspectErr
 }
 
+// passPlaceholderCtx passes expr to call instead of propagating a context parameter into its caller.
+func passPlaceholderCtx(params processCallSitesParams, call *ast.CallExpr, expr string) {
+	ensureContextImportFor(params.pkg.Fset, params.fileAST, expr)
+	ensureCallHasCtxArg(params.pkg, call, expr, params.cp)
+	markCurrentFileModified(params)
+}
+
 // Helper: mark the concrete filename modified.
 func markFileModified(mod map[string]bool, fset *token.FileSet, file *ast.File) {
 	if fset == nil || file == nil {
@@ -526,27 +880,6 @@ func markFileModified(mod map[string]bool, fset *token.FileSet, file *ast.File)
 	}
 }
 
-// writeModified writes all package files back using canonical gofmt formatting.
-func writeModified(pkgs []*packages.Package) error {
-	for _, thePkg := range pkgs {
-		for _, syntaxTree := range thePkg.Syntax {
-			filename := thePkg.Fset.File(syntaxTree.Pos()).Name()
-			slog.Debug("writing file", slog.String("file", filename))
-			var buf bytes.Buffer
-			// Format using go/format to preserve standard gofmt style and comments
-			if err := format.Node(&buf, thePkg.Fset, syntaxTree); err != nil {
-				return fmt.Errorf("formatting file %s: %w", filename, err)
-			}
-			if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil { //nolint:gosec // Appropriate permissions for source files
-				return fmt.Errorf("writing file %s: %w", filename, err)
-			}
-			slog.Debug("wrote file", slog.String("file", filename))
-		}
-	}
-
-	return nil
-}
-
 // Utility.
 func firstNonEmpty(vals ...string) string {
 	for _, v := range vals {
@@ -559,9 +892,9 @@ func firstNonEmpty(vals ...string) string {
 }
 
 // functionHasContextParam reports whether the given function declaration already has
-// a parameter of type context.Context, regardless of its name. This influences whether
+// a parameter of a context type, regardless of its name. This influences whether
 // we need to traverse callers: when true, callers already pass the corresponding argument.
-func functionHasContextParam(fn *ast.FuncDecl, info *types.Info) bool {
+func functionHasContextParam(fn *ast.FuncDecl, info *types.Info, cp ctxParam) bool {
 	if fn == nil || fn.Type == nil || fn.Type.Params == nil {
 		return false
 	}
@@ -569,7 +902,7 @@ func functionHasContextParam(fn *ast.FuncDecl, info *types.Info) bool {
 		if field == nil || field.Type == nil {
 			continue
 		}
-		if isContextType(info, field.Type) {
+		if isContextType(info, field.Type, cp) {
 			return true
 		}
 	}
@@ -0,0 +1,511 @@
+package goctx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/tools/go/packages"
+)
+
+// Edit is a single intended change to a source file: the bytes [Start, End) of the original
+// file content are replaced by Replacement.
+type Edit struct {
+	File        string // absolute path of the file
+	Start       int    // byte offset into the original content
+	End         int    // byte offset into the original content (exclusive)
+	Line        int    // 1-based line of Start in the original content
+	Replacement string
+	Reason      string // why the change is made
+	Function    string // fully qualified function the edit belongs to; empty for file-level edits (imports)
+
+	lastLine int // 1-based last original line covered by [Start, End); equals Line for insertions
+}
+
+// ChangePlan is the list of edits goctx intends to make. It is produced by Plan, can be inspected
+// or merged with other plans, and is written with Apply.
+type ChangePlan struct {
+	Edits []Edit
+	// Debt lists the call sites where an exported signature was preserved (PreserveExported).
+	Debt []APIDebt
+	// Truncated lists the call sites where propagation was cut at Options.MaxDepth.
+	Truncated []Truncation
+	// Manual lists calls to functions without a context-aware variant (see StdlibUpgrade.Hint).
+	Manual []ManualUpgrade
+	// hashes records the SHA-256 of each file's content the edits were computed against.
+	hashes map[string][sha256.Size]byte
+}
+
+// APIDebt is a call site inside an exported function of a library package that was passed a
+// placeholder context instead of changing the function's public signature.
+type APIDebt struct {
+	Pos      token.Position // the call site
+	Function string         // fully qualified exported function whose signature was preserved
+	Callee   string         // the function now requiring a context
+	Expr     string         // the placeholder passed, e.g. context.TODO()
+}
+
+func (d APIDebt) String() string {
+	return fmt.Sprintf("%s: %s passes %s to %s; its exported signature was preserved", d.Pos, d.Function, d.Expr, d.Callee)
+}
+
+// Truncation is a call site more than Options.MaxDepth caller levels above a target that was
+// passed a placeholder context instead of propagating further.
+type Truncation struct {
+	Pos      token.Position // the call site
+	Function string         // fully qualified function that kept its signature
+	Callee   string         // the function now requiring a context
+	Expr     string         // the placeholder passed, i.e. context.TODO()
+	Depth    int            // caller level of Function above the target
+}
+
+func (t Truncation) String() string {
+	return fmt.Sprintf("%s: %s passes %s to %s; propagation stopped at depth %d", t.Pos, t.Function, t.Expr, t.Callee, t.Depth)
+}
+
+// FS is the file system a ChangePlan is applied to.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+}
+
+// OSFS is an FS backed by the operating system.
+type OSFS struct{}
+
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name) //nolint:wrapcheck // Thin adapter over the os package.
+}
+
+func (OSFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm) //nolint:wrapcheck // Thin adapter over the os package.
+}
+
+// Files returns the sorted list of files touched by the plan.
+func (p *ChangePlan) Files() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, e := range p.Edits {
+		if !seen[e.File] {
+			seen[e.File] = true
+			files = append(files, e.File)
+		}
+	}
+	sort.Strings(files)
+
+	return files
+}
+
+// Functions returns the sorted, de-duplicated fully qualified names of the functions the plan
+// modifies.
+func (p *ChangePlan) Functions() []string {
+	seen := make(map[string]bool)
+	var funcs []string
+	for _, e := range p.Edits {
+		if e.Function != "" && !seen[e.Function] {
+			seen[e.Function] = true
+			funcs = append(funcs, e.Function)
+		}
+	}
+	sort.Strings(funcs)
+
+	return funcs
+}
+
+// Merge combines several plans computed against the same file contents into one. Identical edits
+// are kept once; overlapping, differing edits are reported as a conflict.
+func Merge(plans ...*ChangePlan) (*ChangePlan, error) {
+	merged := &ChangePlan{hashes: make(map[string][sha256.Size]byte)}
+	for _, plan := range plans {
+		if plan == nil {
+			continue
+		}
+		for file, hash := range plan.hashes {
+			if prev, ok := merged.hashes[file]; ok && prev != hash {
+				return nil, fmt.Errorf("plans were computed against different contents of %s", file)
+			}
+			merged.hashes[file] = hash
+		}
+		merged.Edits = append(merged.Edits, plan.Edits...)
+		merged.Debt = append(merged.Debt, plan.Debt...)
+		merged.Truncated = append(merged.Truncated, plan.Truncated...)
+		merged.Manual = append(merged.Manual, plan.Manual...)
+	}
+	sortEdits(merged.Edits)
+	sort.SliceStable(merged.Manual, func(i, j int) bool { return positionLess(merged.Manual[i].Pos, merged.Manual[j].Pos) })
+
+	deduped := merged.Edits[:0]
+	for _, e := range merged.Edits {
+		if n := len(deduped); n > 0 {
+			last := deduped[n-1]
+			if last.File == e.File && last.Start == e.Start && last.End == e.End && last.Replacement == e.Replacement {
+				continue
+			}
+			if last.File == e.File && e.Start < last.End {
+				return nil, fmt.Errorf("conflicting edits in %s at line %d (%s vs. %s)", e.File, e.Line, last.Reason, e.Reason)
+			}
+		}
+		deduped = append(deduped, e)
+	}
+	merged.Edits = deduped
+
+	return merged, nil
+}
+
+// Apply writes the plan's edits through fsys. It refuses to touch a file whose content changed
+// since the plan was computed. All files are read and verified before the first one is written;
+// cancelling ctx stops before the next file is written and returns an error wrapping ctx.Err().
+func (p *ChangePlan) Apply(ctx context.Context, fsys FS) error {
+	byFile := make(map[string][]Edit)
+	for _, e := range p.Edits {
+		byFile[e.File] = append(byFile[e.File], e)
+	}
+	files := p.Files()
+	updates := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := fsys.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+		if want, ok := p.hashes[file]; ok && sha256.Sum256(content) != want {
+			return fmt.Errorf("%s changed since the plan was computed", file)
+		}
+		updates[i], err = applyEdits(content, byFile[file])
+		if err != nil {
+			return fmt.Errorf("applying edits to %s: %w", file, err)
+		}
+	}
+	for i, file := range files {
+		if err := interrupted(ctx, "writing files"); err != nil {
+			return err
+		}
+		slog.Debug("writing file", slog.String("file", file))
+		if err := fsys.WriteFile(file, updates[i], 0o644); err != nil { //nolint:gosec // Appropriate permissions for source files
+			return fmt.Errorf("writing file %s: %w", file, err)
+		}
+		slog.Debug("wrote file", slog.String("file", file))
+	}
+
+	return nil
+}
+
+// applyEdits applies non-overlapping edits to content.
+func applyEdits(content []byte, edits []Edit) ([]byte, error) {
+	sorted := append([]Edit(nil), edits...)
+	sortEdits(sorted)
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range sorted {
+		if e.Start < last || e.End < e.Start || e.End > len(content) {
+			return nil, fmt.Errorf("invalid or overlapping edit at line %d", e.Line)
+		}
+		buf.Write(content[last:e.Start])
+		buf.WriteString(e.Replacement)
+		last = e.End
+	}
+	buf.Write(content[last:])
+
+	return buf.Bytes(), nil
+}
+
+func sortEdits(edits []Edit) {
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].File != edits[j].File {
+			return edits[i].File < edits[j].File
+		}
+		if edits[i].Start != edits[j].Start {
+			return edits[i].Start < edits[j].Start
+		}
+
+		return edits[i].End < edits[j].End
+	})
+}
+
+// changeLog records why functions were modified during propagation so that plan edits can be
+// attributed to a reason and a function.
+type changeLog struct {
+	reasons  map[*ast.FuncDecl][]string
+	types    map[*ast.GenDecl][]string // reasons for modifying type declarations
+	debt     []APIDebt
+	cuts     []Truncation
+	manual   []ManualUpgrade
+	compat   map[string]bool            // by funcKey, so that test variants agree
+	wrappers map[string][]compatWrapper // by filename
+	removed  map[string][]int           // lines of deleted nodes, by filename
+}
+
+func newChangeLog() *changeLog {
+	return &changeLog{
+		reasons:  make(map[*ast.FuncDecl][]string),
+		types:    make(map[*ast.GenDecl][]string),
+		compat:   make(map[string]bool),
+		wrappers: make(map[string][]compatWrapper),
+		removed:  make(map[string][]int),
+	}
+}
+
+// note records a reason for modifying fn; duplicate reasons are recorded once.
+func (c *changeLog) note(fn *ast.FuncDecl, format string, args ...any) {
+	if c == nil || fn == nil {
+		return
+	}
+	reason := fmt.Sprintf(format, args...)
+	for _, r := range c.reasons[fn] {
+		if r == reason {
+			return
+		}
+	}
+	c.reasons[fn] = append(c.reasons[fn], reason)
+}
+
+// noteType records a reason for modifying the type declaration decl.
+func (c *changeLog) noteType(decl *ast.GenDecl, format string, args ...any) {
+	if c == nil || decl == nil {
+		return
+	}
+	if reason := fmt.Sprintf(format, args...); !slices.Contains(c.types[decl], reason) {
+		c.types[decl] = append(c.types[decl], reason)
+	}
+}
+
+// addDebt records a preserved exported signature. Files shared with test variants are visited
+// more than once, so a call site is recorded once.
+func (c *changeLog) addDebt(d APIDebt) {
+	if c != nil && !slices.Contains(c.debt, d) {
+		c.debt = append(c.debt, d)
+	}
+}
+
+// addTruncation records a call site where propagation was cut at the maximum depth.
+func (c *changeLog) addTruncation(t Truncation) {
+	if c != nil && !slices.Contains(c.cuts, t) {
+		c.cuts = append(c.cuts, t)
+	}
+}
+
+// addManual records a call that has no context-aware variant.
+func (c *changeLog) addManual(m ManualUpgrade) {
+	if c != nil && !slices.Contains(c.manual, m) {
+		c.manual = append(c.manual, m)
+	}
+}
+
+// markCompat records that obj was renamed to its context-aware sibling and that filename needs
+// the deprecated wrapper w.
+func (c *changeLog) markCompat(obj types.Object, filename string, w compatWrapper) {
+	c.compat[funcKey(obj)] = true
+	c.wrappers[filename] = append(c.wrappers[filename], w)
+}
+
+// removeLines records that the nodes on lines first..last of filename were deleted.
+func (c *changeLog) removeLines(filename string, first, last int) {
+	for line := first; line <= last; line++ {
+		if !slices.Contains(c.removed[filename], line) {
+			c.removed[filename] = append(c.removed[filename], line)
+		}
+	}
+}
+
+// isCompat reports whether obj keeps its signature through a generated wrapper.
+func (c *changeLog) isCompat(obj types.Object) bool {
+	return c != nil && c.compat[funcKey(obj)]
+}
+
+// buildPlan formats every modified file and diffs it against its original content, turning
+// each differing hunk into an Edit attributed to the enclosing function.
+func buildPlan(pkgs []*packages.Package, modifiedFiles map[string]bool, log *changeLog) (*ChangePlan, error) {
+	type fileInfo struct {
+		pkg  *packages.Package
+		file *ast.File
+	}
+	files := make(map[string]fileInfo)
+	var names []string
+	for _, pkg := range pkgs {
+		for _, syntaxTree := range pkg.Syntax {
+			tf := pkg.Fset.File(syntaxTree.Pos())
+			if tf == nil || !modifiedFiles[tf.Name()] {
+				continue
+			}
+			if _, ok := files[tf.Name()]; !ok {
+				names = append(names, tf.Name())
+			}
+			files[tf.Name()] = fileInfo{pkg: pkg, file: syntaxTree}
+		}
+	}
+	sort.Strings(names)
+
+	plan := &ChangePlan{hashes: make(map[string][sha256.Size]byte)}
+	if log != nil {
+		plan.Debt = append(plan.Debt, log.debt...)
+		sort.SliceStable(plan.Debt, func(i, j int) bool { return positionLess(plan.Debt[i].Pos, plan.Debt[j].Pos) })
+		plan.Truncated = append(plan.Truncated, log.cuts...)
+		sort.SliceStable(plan.Truncated, func(i, j int) bool { return positionLess(plan.Truncated[i].Pos, plan.Truncated[j].Pos) })
+		plan.Manual = append(plan.Manual, log.manual...)
+		sort.SliceStable(plan.Manual, func(i, j int) bool { return positionLess(plan.Manual[i].Pos, plan.Manual[j].Pos) })
+	}
+	for _, name := range names {
+		info := files[name]
+		original, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		var removed []int
+		if log != nil {
+			removed = log.removed[name]
+		}
+		var buf bytes.Buffer
+		// Format using go/format to preserve standard gofmt style and comments
+		if err := formatWithoutLines(&buf, info.pkg.Fset, info.file, removed); err != nil {
+			return nil, fmt.Errorf("formatting file %s: %w", name, err)
+		}
+		updated := buf.Bytes()
+		if log != nil && len(log.wrappers[name]) > 0 {
+			if updated, err = insertCompatWrappers(name, updated, log.wrappers[name]); err != nil {
+				return nil, err
+			}
+		}
+		if bytes.Equal(original, updated) {
+			continue
+		}
+		plan.hashes[name] = sha256.Sum256(original)
+		for _, e := range diffEdits(name, original, updated) {
+			e.Function, e.Reason = attributeEdit(info.pkg, info.file, e, log)
+			plan.Edits = append(plan.Edits, e)
+		}
+	}
+	sortEdits(plan.Edits)
+
+	return plan, nil
+}
+
+// formatWithoutLines formats file as if the given lines, whose nodes were deleted, did not exist,
+// so that the printer does not leave blank lines in their place. The line table is restored
+// afterwards, as edits are attributed by original line.
+func formatWithoutLines(buf *bytes.Buffer, fset *token.FileSet, file *ast.File, removed []int) error {
+	tf := fset.File(file.Pos())
+	if tf == nil || len(removed) == 0 {
+		return format.Node(buf, fset, file) //nolint:wrapcheck // Wrapped by the caller.
+	}
+	lines := tf.Lines()
+	kept := make([]int, 0, len(lines))
+	for i, offset := range lines {
+		if i == 0 || !slices.Contains(removed, i+1) {
+			kept = append(kept, offset)
+		}
+	}
+	if tf.SetLines(kept) {
+		defer tf.SetLines(lines)
+	}
+
+	return format.Node(buf, fset, file) //nolint:wrapcheck // Wrapped by the caller.
+}
+
+func positionLess(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+
+	return a.Offset < b.Offset
+}
+
+// diffEdits computes line-granular edits turning original into updated.
+func diffEdits(name string, original, updated []byte) []Edit {
+	a := splitLinesKeepEOL(string(original))
+	b := splitLinesKeepEOL(string(updated))
+	offsets := make([]int, len(a)+1)
+	for i, l := range a {
+		offsets[i+1] = offsets[i] + len(l)
+	}
+
+	var edits []Edit
+	for _, op := range difflib.NewMatcherWithJunk(a, b, false, nil).GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+		edits = append(edits, Edit{
+			File:        name,
+			Start:       offsets[op.I1],
+			End:         offsets[op.I2],
+			Line:        op.I1 + 1,
+			lastLine:    max(op.I1+1, op.I2),
+			Replacement: strings.Join(b[op.J1:op.J2], ""),
+		})
+	}
+
+	return edits
+}
+
+func splitLinesKeepEOL(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+
+	return lines
+}
+
+// attributeEdit finds the function declaration spanning the edit's original lines and returns its
+// qualified name and the reasons recorded for it.
+func attributeEdit(pkg *packages.Package, file *ast.File, e Edit, log *changeLog) (string, string) {
+	firstLine := e.Line
+	lastLine := max(e.Line, e.lastLine)
+	if e.Start == e.End {
+		// A pure insertion belongs to the declaration it follows, e.g. a generated wrapper.
+		firstLine = max(1, e.Line-1)
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			if gen, ok := decl.(*ast.GenDecl); ok && log != nil && len(log.types[gen]) > 0 &&
+				firstLine <= pkg.Fset.Position(gen.End()).Line && lastLine >= pkg.Fset.Position(gen.Pos()).Line {
+				return "", strings.Join(log.types[gen], "; ")
+			}
+
+			continue
+		}
+		start := pkg.Fset.Position(fn.Pos()).Line
+		end := pkg.Fset.Position(fn.End()).Line
+		if fn.Doc != nil {
+			start = pkg.Fset.Position(fn.Doc.Pos()).Line
+		}
+		if firstLine > end || lastLine < start {
+			continue
+		}
+		name := funcKeyOfDecl(pkg, fn)
+		reason := "update " + fn.Name.Name
+		if log != nil && len(log.reasons[fn]) > 0 {
+			reason = strings.Join(log.reasons[fn], "; ")
+		}
+
+		return name, reason
+	}
+
+	return "", "update imports"
+}
+
+// String renders the edit as a one-line summary: position, function and reason.
+func (e Edit) String() string {
+	pos := token.Position{Filename: e.File, Line: e.Line}
+	if e.Function == "" {
+		return fmt.Sprintf("%s: %s", pos, e.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", pos, e.Function, e.Reason)
+}
@@ -0,0 +1,160 @@
+package goctx
+
+import (
+	"context"
+	"crypto/sha256"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// renderEdits formats plan edits with filenames relative to dir, one per line.
+func renderEdits(t *testing.T, dir string, plan *ChangePlan) []byte {
+	t.Helper()
+
+	var sb strings.Builder
+	for _, e := range plan.Edits {
+		rel, err := filepath.Rel(dir, e.File)
+		require.NoError(t, err)
+		e.File = filepath.ToSlash(rel)
+		sb.WriteString(e.String())
+		sb.WriteString("\n")
+	}
+
+	return []byte(sb.String())
+}
+
+// renderManual renders plan.Manual one call per line, with paths relative to dir.
+func renderManual(t *testing.T, dir string, plan *ChangePlan) []byte {
+	t.Helper()
+
+	var sb strings.Builder
+	for _, m := range plan.Manual {
+		rel, err := filepath.Rel(dir, m.Pos.Filename)
+		require.NoError(t, err)
+		m.Pos.Filename = filepath.ToSlash(rel)
+		sb.WriteString(m.String())
+		sb.WriteString("\n")
+	}
+
+	return []byte(sb.String())
+}
+
+func TestE2E_Plan_ThenApply(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	g := genGoldie(t)
+	dir := writeTempModuleFromInput(t)
+	target := filepath.Join(dir, "a", "b.go") + ":Callee"
+
+	before := readAllFiles(t, dir)
+	plan, err := Plan(ctx, Options{Target: target, WorkDir: dir})
+	require.NoError(t, err)
+	require.Equal(t, before, readAllFiles(t, dir), "planning must not modify files")
+	require.Equal(t, []string{
+		filepath.Join(dir, "a", "a.go"),
+		filepath.Join(dir, "a", "b.go"),
+		filepath.Join(dir, "main.go"),
+	}, plan.Files())
+	g.Assert(t, "edits.txt", renderEdits(t, dir, plan))
+
+	// Applying the plan must produce exactly what Run produces.
+	require.NoError(t, plan.Apply(ctx, OSFS{}))
+	runDir := writeTempModuleFromInput(t)
+	require.NoError(t, Run(ctx, Options{Target: filepath.Join(runDir, "a", "b.go") + ":Callee", WorkDir: runDir}))
+	require.Equal(t, readAllFiles(t, runDir), readAllFiles(t, dir))
+}
+
+// memFS is an in-memory FS for plan tests.
+type memFS map[string][]byte
+
+func (m memFS) ReadFile(name string) ([]byte, error) {
+	data, ok := m[name]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+
+	return data, nil
+}
+
+func (m memFS) WriteFile(name string, data []byte, _ fs.FileMode) error {
+	m[name] = data
+
+	return nil
+}
+
+func TestChangePlan_Apply_RefusesChangedFile(t *testing.T) {
+	t.Parallel()
+
+	original := []byte("package a\n\nfunc F() {}\n")
+	plan := &ChangePlan{
+		Edits:  []Edit{{File: "a.go", Start: 11, End: 23, Line: 3, Replacement: "func F(ctx context.Context) {}\n"}},
+		hashes: map[string][sha256.Size]byte{"a.go": sha256.Sum256(original)},
+	}
+
+	fsys := memFS{"a.go": original}
+	require.NoError(t, plan.Apply(t.Context(), fsys))
+	require.Equal(t, "package a\n\nfunc F(ctx context.Context) {}\n", string(fsys["a.go"]))
+
+	// Applying again fails: the file no longer matches the content the plan was computed against.
+	require.ErrorContains(t, plan.Apply(t.Context(), fsys), "changed since the plan was computed")
+	require.ErrorIs(t, plan.Apply(t.Context(), memFS{}), fs.ErrNotExist)
+}
+
+func TestChangePlan_Apply_CancelledWritesNothing(t *testing.T) {
+	t.Parallel()
+
+	original := []byte("package a\n")
+	plan := &ChangePlan{Edits: []Edit{{File: "a.go", Start: 0, End: 10, Line: 1, Replacement: "package b\n"}}}
+	fsys := memFS{"a.go": original}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := plan.Apply(ctx, fsys)
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorContains(t, err, "writing files interrupted")
+	require.Equal(t, original, fsys["a.go"])
+}
+
+func TestMerge_DedupesAndReportsConflicts(t *testing.T) {
+	t.Parallel()
+
+	hashes := map[string][sha256.Size]byte{"a.go": sha256.Sum256([]byte("x"))}
+	edit := Edit{File: "a.go", Start: 0, End: 4, Line: 1, Replacement: "one\n", Reason: "first"}
+	other := Edit{File: "a.go", Start: 10, End: 12, Line: 3, Replacement: "two\n", Reason: "second"}
+
+	merged, err := Merge(
+		&ChangePlan{Edits: []Edit{other, edit}, hashes: hashes},
+		&ChangePlan{Edits: []Edit{edit}, hashes: hashes},
+		nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, []Edit{edit, other}, merged.Edits)
+
+	conflicting := edit
+	conflicting.Replacement = "uno\n"
+	_, err = Merge(&ChangePlan{Edits: []Edit{edit}}, &ChangePlan{Edits: []Edit{conflicting}})
+	require.ErrorContains(t, err, "conflicting edits in a.go at line 1")
+
+	_, err = Merge(
+		&ChangePlan{hashes: hashes},
+		&ChangePlan{hashes: map[string][sha256.Size]byte{"a.go": sha256.Sum256([]byte("y"))}},
+	)
+	require.ErrorContains(t, err, "different contents of a.go")
+}
+
+func TestMerge_KeepsManualUpgrades(t *testing.T) {
+	t.Parallel()
+
+	get := ManualUpgrade{Pos: token.Position{Filename: "a.go", Offset: 40, Line: 3}, Function: "a.fetch", Callee: "net/http.Get"}
+	notify := ManualUpgrade{Pos: token.Position{Filename: "a.go", Offset: 5, Line: 1}, Function: "a.main", Callee: "os/signal.Notify"}
+
+	merged, err := Merge(&ChangePlan{Manual: []ManualUpgrade{get}}, &ChangePlan{Manual: []ManualUpgrade{notify}})
+	require.NoError(t, err)
+	require.Equal(t, []ManualUpgrade{notify, get}, merged.Manual)
+}
@@ -0,0 +1,112 @@
+// Package plugin registers goctx's context-chain checks as a golangci-lint module plugin.
+//
+// Enable it by listing this module in .custom-gcl.yml:
+//
+//	version: v2.12.2
+//	plugins:
+//	  - module: github.com/preminger/goctx
+//	    import: github.com/preminger/goctx/pkg/goctx/plugin
+//	    version: latest
+//
+// and configuring the linter in .golangci.yml:
+//
+//	linters:
+//	  enable:
+//	    - goctx
+//	  settings:
+//	    custom:
+//	      goctx:
+//	        type: module
+//	        settings:
+//	          http: true
+//	          cli: true
+//	          web: [gin, echo]
+//	          stop-at: internal/api/server.go:Serve
+//	          ctx-name: ctx
+//	          boundaries:
+//	            - type: "*github.com/spf13/cobra.Command"
+//	              expr: "{{.Param}}.Context()"
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golangci/plugin-module-register/register"
+	"github.com/preminger/goctx/pkg/goctx"
+	"github.com/preminger/goctx/pkg/goctx/analyzer"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Name is the linter name under which the plugin is registered.
+const Name = "goctx"
+
+//nolint:gochecknoinits // Registration through init is how golangci-lint discovers module plugins.
+func init() {
+	register.Plugin(Name, New)
+}
+
+// Settings mirrors the boundary-related CLI flags of goctx.
+type Settings struct {
+	// HTTP treats http.HandlerFunc boundaries as having a ctx available via req.Context().
+	HTTP bool `json:"http"`
+	// StopAt lists terminating boundaries: functions of the form path/to/file.go:FuncName[:N],
+	// package patterns or re:REGEXP. A single string is accepted as well as a list.
+	StopAt StringList `json:"stop-at"`
+	// CLI treats cobra and urfave/cli callbacks as boundaries deriving ctx from the command.
+	CLI bool `json:"cli"`
+	// Web lists web frameworks whose handlers are boundaries deriving ctx from the request:
+	// gin, echo, fiber, chi. A single string is accepted as well as a list.
+	Web StringList `json:"web"`
+	// CtxName names context parameters introduced by suggested fixes (default ctx).
+	CtxName string `json:"ctx-name"`
+	// Boundaries lists boundary rules deriving ctx from a parameter type, as in the boundary
+	// rules file of --boundary-rules.
+	Boundaries []goctx.BoundaryRule `json:"boundaries"`
+}
+
+// Options converts the settings into goctx options.
+func (s Settings) Options() goctx.Options {
+	return goctx.Options{HTML: s.HTTP, StopAts: s.StopAt, CLI: s.CLI, Web: s.Web, CtxName: s.CtxName, BoundaryRules: s.Boundaries}
+}
+
+// StringList is a list of strings that can also be given as a single string in the settings.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("want a string or a list of strings: %w", err)
+	}
+	*l = list
+
+	return nil
+}
+
+type linterPlugin struct {
+	settings Settings
+}
+
+// New decodes the golangci-lint settings and returns the plugin.
+func New(rawSettings any) (register.LinterPlugin, error) {
+	settings, err := register.DecodeSettings[Settings](rawSettings)
+	if err != nil {
+		return nil, fmt.Errorf("decoding goctx settings: %w", err)
+	}
+
+	return &linterPlugin{settings: settings}, nil
+}
+
+func (p *linterPlugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{analyzer.New(p.settings.Options())}, nil
+}
+
+func (p *linterPlugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
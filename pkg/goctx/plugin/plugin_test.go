@@ -0,0 +1,70 @@
+package plugin_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golangci/plugin-module-register/register"
+	"github.com/preminger/goctx/pkg/goctx/plugin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestPlugin_RegisteredWithSettings(t *testing.T) {
+	newPlugin, err := register.GetPlugin(plugin.Name)
+	require.NoError(t, err)
+
+	linter, err := newPlugin(map[string]any{"http": true, "ctx-name": "c"})
+	require.NoError(t, err)
+	require.Equal(t, register.LoadModeTypesInfo, linter.GetLoadMode())
+
+	analyzers, err := linter.BuildAnalyzers()
+	require.NoError(t, err)
+	require.Len(t, analyzers, 1)
+
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), analyzers[0], "b")
+}
+
+func TestPlugin_RejectsUnknownSettings(t *testing.T) {
+	_, err := plugin.New(map[string]any{"no-such-setting": true})
+	require.Error(t, err)
+}
+
+func TestSettings_StopAtStringOrList(t *testing.T) {
+	var settings plugin.Settings
+	require.NoError(t, json.Unmarshal([]byte(`{"stop-at": "a.go:F"}`), &settings))
+	require.Equal(t, []string{"a.go:F"}, settings.Options().StopAts)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"stop-at": ["./api/...", "re:^x"]}`), &settings))
+	require.Equal(t, []string{"./api/...", "re:^x"}, settings.Options().StopAts)
+
+	require.Error(t, json.Unmarshal([]byte(`{"stop-at": 1}`), &settings))
+}
+
+func TestPlugin_BoundaryRules(t *testing.T) {
+	linter, err := plugin.New(map[string]any{
+		"boundaries": []any{map[string]any{"type": "*c.Job", "expr": "{{.Param}}.Ctx()"}},
+	})
+	require.NoError(t, err)
+
+	analyzers, err := linter.BuildAnalyzers()
+	require.NoError(t, err)
+	require.Len(t, analyzers, 1)
+
+	analysistest.Run(t, analysistest.TestData(), analyzers[0], "c")
+}
+
+func TestSettings_CLI(t *testing.T) {
+	var settings plugin.Settings
+	require.NoError(t, json.Unmarshal([]byte(`{"cli": true}`), &settings))
+	require.True(t, settings.Options().CLI)
+}
+
+func TestSettings_WebStringOrList(t *testing.T) {
+	var settings plugin.Settings
+	require.NoError(t, json.Unmarshal([]byte(`{"web": "gin"}`), &settings))
+	require.Equal(t, []string{"gin"}, settings.Options().Web)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"web": ["echo", "chi"]}`), &settings))
+	require.Equal(t, []string{"echo", "chi"}, settings.Options().Web)
+}
@@ -0,0 +1,12 @@
+package b
+
+import "context"
+
+func load(ctx context.Context) string {
+	_ = ctx
+	return "x"
+}
+
+func ignoresCtx(_ context.Context) {
+	_ = load(context.Background()) // want `context parameter "_" of ignoresCtx is unused, but callee b.load needs a context`
+}
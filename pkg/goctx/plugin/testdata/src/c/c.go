@@ -0,0 +1,20 @@
+package c
+
+import "context"
+
+type Job struct {
+	ctx context.Context
+}
+
+func (j *Job) Ctx() context.Context {
+	return j.ctx
+}
+
+func load(ctx context.Context) string {
+	_ = ctx
+	return "x"
+}
+
+func run(j *Job) { // want run:"createsContext"
+	_ = load(context.Background()) // want `run passes a root context to c.load although a ctx is in scope`
+}
@@ -0,0 +1,507 @@
+package goctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Remove plans removing the context parameter of the targets, the reverse of Plan. A target's
+// context must be unused in its body, or only passed on to functions whose context parameter is
+// removed too. From the targets, removal walks down the functions they pass the context to, and
+// up the callers that pass their own context parameter to a removed function, stopping at the
+// same boundaries as Plan. Those functions lose their parameter when they no longer need it: it
+// is then unused, or only passed on to removed functions. Call sites drop the argument, local
+// context variables left unused are removed, and so are imports of the context package.
+func Remove(ctx context.Context, opts Options) (*ChangePlan, error) {
+	targets := opts.targetSpecs()
+	if len(targets) == 0 {
+		return nil, errors.New("missing target argument")
+	}
+	if opts.Param != nil {
+		return nil, errors.New("remove applies to context parameters only")
+	}
+	filter, err := newFileFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	stops, err := parseStopSpecs(opts)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := loadAllPackages(ctx, firstNonEmpty(opts.WorkDir, "."), opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := resolveCtxParam(opts, pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiedFiles := make(map[string]bool)
+	changes := newChangeLog()
+	r := newRemover(pkgs, opts, cp, stops, newEditor(cp, modifiedFiles, changes))
+	for _, target := range targets {
+		spec, err := parseTargetSpec(target)
+		if err != nil {
+			return nil, fmt.Errorf("parsing target %s: %w", target, err)
+		}
+		res, err := resolveTarget(pkgs, spec)
+		if err != nil {
+			return nil, fmt.Errorf("resolving target %s: %w", target, err)
+		}
+		r.targets[funcKey(res.Obj)] = true
+	}
+	if err := interrupted(ctx, "removing context"); err != nil {
+		return nil, err
+	}
+	removed, err := r.plan()
+	if err != nil {
+		return nil, err
+	}
+	r.apply(removed)
+	r.tidy()
+
+	plan, err := buildPlan(pkgs, modifiedFiles, changes)
+	if err != nil {
+		return nil, fmt.Errorf("planning edits: %w", err)
+	}
+	slog.Debug("remove done", slog.Int("functions", len(removed)), slog.Int("edits", len(plan.Edits)))
+	if err := checkFilteredFiles(plan, filter); err != nil {
+		return nil, err
+	}
+	if funcs := plan.Functions(); opts.MaxChanges > 0 && len(funcs) > opts.MaxChanges {
+		return nil, fmt.Errorf("plan would modify %d functions, more than the maximum of %d: %s",
+			len(funcs), opts.MaxChanges, strings.Join(funcs, ", "))
+	}
+
+	return plan, nil
+}
+
+// ctxUse describes the context parameter of a function declaration and how its body uses it.
+type ctxUse struct {
+	ref   funcRef
+	field *ast.Field
+	obj   types.Object // the parameter; nil when it is unnamed or blank
+	index int          // position among the parameters, as at call sites
+	// forwards are the functions the parameter is passed to as their own context parameter.
+	forwards []string
+	// other is set when the parameter is used in any other way.
+	other bool
+}
+
+// removeCall is a call of a function whose context parameter may be removed.
+type removeCall struct {
+	pkg    *packages.Package
+	file   *ast.File
+	call   *ast.CallExpr
+	caller *ast.FuncDecl
+}
+
+// remover holds the state of a Remove run.
+type remover struct {
+	*editor
+
+	opts    Options
+	stops   *stopSet
+	pkgs    []*packages.Package
+	targets map[string]bool
+
+	decls     map[string]funcRef
+	calls     map[string][]removeCall
+	valueUses map[string]bool
+	uses      map[string]*ctxUse
+}
+
+// newRemover indexes the function declarations of pkgs and the calls between them.
+func newRemover(pkgs []*packages.Package, opts Options, cp ctxParam, stops *stopSet, e *editor) *remover {
+	r := &remover{
+		editor:    e,
+		opts:      opts,
+		stops:     stops,
+		pkgs:      pkgs,
+		targets:   make(map[string]bool),
+		decls:     make(map[string]funcRef),
+		calls:     make(map[string][]removeCall),
+		valueUses: make(map[string]bool),
+		uses:      make(map[string]*ctxUse),
+	}
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			callees := make(map[*ast.Ident]bool)
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				if obj := pkg.TypesInfo.Defs[fn.Name]; obj != nil {
+					r.decls[funcKey(obj)] = funcRef{pkg: pkg, file: file, fn: fn}
+				}
+				ast.Inspect(fn, func(n ast.Node) bool {
+					call, ok := n.(*ast.CallExpr)
+					if !ok {
+						return true
+					}
+					if f, ok := calledFunc(pkg.TypesInfo, call); ok {
+						key := funcKey(f)
+						r.calls[key] = append(r.calls[key], removeCall{pkg: pkg, file: file, call: call, caller: fn})
+						if call.Ellipsis.IsValid() {
+							r.valueUses[key] = true
+						}
+						callees[calleeIdent(call.Fun)] = true
+					}
+
+					return true
+				})
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && !callees[id] {
+					if f, ok := pkg.TypesInfo.Uses[id].(*types.Func); ok {
+						r.valueUses[funcKey(f)] = true
+					}
+				}
+
+				return true
+			})
+		}
+	}
+
+	return r
+}
+
+// calledFunc returns the function or method called by call.
+func calledFunc(info *types.Info, call *ast.CallExpr) (*types.Func, bool) {
+	obj, _ := resolveCalled(info, call.Fun)
+	f, ok := obj.(*types.Func)
+
+	return f, ok
+}
+
+// ctxParamOf returns the context parameter of the function declared as key, or nil.
+func (r *remover) ctxParamOf(key string) *ctxUse {
+	if u, ok := r.uses[key]; ok {
+		return u
+	}
+	r.uses[key] = nil
+	ref, ok := r.decls[key]
+	if !ok || ref.fn.Type.Params == nil {
+		return nil
+	}
+	index := 0
+	for _, field := range ref.fn.Type.Params.List {
+		if isContextType(ref.pkg.TypesInfo, field.Type, r.cp) {
+			if len(field.Names) > 1 {
+				return nil
+			}
+			u := &ctxUse{ref: ref, field: field, index: index}
+			if len(field.Names) == 1 && field.Names[0].Name != "_" {
+				u.obj = ref.pkg.TypesInfo.Defs[field.Names[0]]
+			}
+			r.uses[key] = u
+
+			return u
+		}
+		index += max(1, len(field.Names))
+	}
+
+	return nil
+}
+
+// analyze returns the context parameter of the function declared as key with the uses of it.
+func (r *remover) analyze(key string) *ctxUse {
+	u := r.ctxParamOf(key)
+	if u == nil || u.obj == nil || u.forwards != nil || u.other || u.ref.fn.Body == nil {
+		return u
+	}
+	info := u.ref.pkg.TypesInfo
+	forwarded := make(map[*ast.Ident]bool)
+	u.forwards = []string{}
+	ast.Inspect(u.ref.fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || call.Ellipsis.IsValid() {
+			return true
+		}
+		f, ok := calledFunc(info, call)
+		if !ok {
+			return true
+		}
+		callee := r.ctxParamOf(funcKey(f))
+		if callee == nil || callee.index >= len(call.Args) {
+			return true
+		}
+		if id, ok := call.Args[callee.index].(*ast.Ident); ok && info.Uses[id] == u.obj {
+			forwarded[id] = true
+			if !slices.Contains(u.forwards, funcKey(f)) {
+				u.forwards = append(u.forwards, funcKey(f))
+			}
+		}
+
+		return true
+	})
+	ast.Inspect(u.ref.fn.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == u.obj && !forwarded[id] {
+			u.other = true
+		}
+
+		return !u.other
+	})
+
+	return u
+}
+
+// plan returns the functions whose context parameter is removed, keyed by funcKey.
+func (r *remover) plan() (map[string]*ctxUse, error) {
+	candidates := make(map[string]*ctxUse)
+	var queue []string
+	for key := range r.targets {
+		u := r.analyze(key)
+		if err := r.checkTarget(key, u); err != nil {
+			return nil, err
+		}
+		candidates[key] = u
+		queue = append(queue, key)
+	}
+	slices.Sort(queue)
+
+	// Walk down the functions the context is passed to, and up the callers passing theirs.
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		u := r.analyze(key)
+		next := append([]string(nil), u.forwards...)
+		for _, c := range r.calls[key] {
+			callerObj := c.pkg.TypesInfo.Defs[c.caller.Name]
+			if callerObj == nil || u.index >= len(c.call.Args) {
+				continue
+			}
+			caller := r.analyze(funcKey(callerObj))
+			if id, ok := c.call.Args[u.index].(*ast.Ident); ok && caller != nil && caller.obj != nil && c.pkg.TypesInfo.Uses[id] == caller.obj {
+				next = append(next, funcKey(callerObj))
+			}
+		}
+		for _, k := range next {
+			if _, ok := candidates[k]; ok {
+				continue
+			}
+			if v := r.analyze(k); v != nil && r.eligible(k, v) {
+				candidates[k] = v
+				queue = append(queue, k)
+			}
+		}
+	}
+
+	// Keep the functions whose context is still needed by a function that keeps its own.
+	for changed := true; changed; {
+		changed = false
+		for key, u := range candidates {
+			if r.targets[key] {
+				continue
+			}
+			if slices.ContainsFunc(u.forwards, func(k string) bool { return candidates[k] == nil }) {
+				delete(candidates, key)
+				changed = true
+			}
+		}
+	}
+	for key := range r.targets {
+		for _, k := range candidates[key].forwards {
+			if candidates[k] == nil {
+				return nil, fmt.Errorf("cannot remove the %s of %s: it is passed to %s, which needs it", r.cp.noun(), key, k)
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// checkTarget reports why the context parameter of the target declared as key cannot be
+// removed, if it cannot.
+func (r *remover) checkTarget(key string, u *ctxUse) error {
+	switch {
+	case u == nil:
+		return fmt.Errorf("cannot remove the %s of %s: it has no %s parameter", r.cp.noun(), key, r.cp.noun())
+	case u.other:
+		return fmt.Errorf("cannot remove the %s of %s: it is used in its body", r.cp.noun(), key)
+	case r.valueUses[key]:
+		return fmt.Errorf("cannot remove the %s of %s: the function is used as a value", r.cp.noun(), key)
+	case r.implementsInterface(u):
+		return fmt.Errorf("cannot remove the %s of %s: the method implements an interface", r.cp.noun(), key)
+	}
+
+	return nil
+}
+
+// eligible reports whether the function declared as key, found by walking from the targets,
+// may lose its context parameter: it does not use it other than by passing it on, its
+// signature is free to change, and it is no boundary.
+func (r *remover) eligible(key string, u *ctxUse) bool {
+	if u.other || u.ref.fn.Body == nil || r.valueUses[key] || r.implementsInterface(u) {
+		return false
+	}
+	stop, reason, err := shouldStopAt(u.ref.fn, u.ref.pkg, r.opts, r.stops)
+	if err != nil || stop {
+		slog.Debug("remove stops at boundary", slog.String("func", key), slog.String("reason", reason.String()))
+
+		return false
+	}
+
+	return true
+}
+
+// implementsInterface reports whether u declares a method of an interface of the loaded
+// packages that its receiver type implements.
+func (r *remover) implementsInterface(u *ctxUse) bool {
+	if u.ref.fn.Recv == nil {
+		return false
+	}
+	f, ok := u.ref.pkg.TypesInfo.Defs[u.ref.fn.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	recv := f.Type().(*types.Signature).Recv().Type()
+	for _, pkg := range r.pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			iface, ok := obj.Type().Underlying().(*types.Interface)
+			if !ok {
+				continue
+			}
+			if m, _, _ := types.LookupFieldOrMethod(iface, false, pkg.Types, f.Name()); m == nil {
+				continue
+			}
+			if types.Implements(recv, iface) || types.Implements(types.NewPointer(recv), iface) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// apply removes the parameters and the arguments of every call of removed, then the context
+// variables of the callers left unused.
+func (r *remover) apply(removed map[string]*ctxUse) {
+	keys := make([]string, 0, len(removed))
+	for key := range removed {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	callers := make(map[*ast.FuncDecl]removeCall)
+	for _, key := range keys {
+		u := removed[key]
+		params := u.ref.fn.Type.Params
+		i := slices.Index(params.List, u.field)
+		prevEnd, nextStart := params.Opening, params.Closing
+		if i > 0 {
+			prevEnd = params.List[i-1].End()
+		}
+		if i+1 < len(params.List) {
+			nextStart = params.List[i+1].Pos()
+		}
+		r.dropNode(u.ref.pkg.Fset, u.ref.file, u.field.Pos(), u.field.End(), prevEnd, nextStart)
+		params.List = slices.Delete(params.List, i, i+1)
+		r.changes.note(u.ref.fn, "drop %s parameter", r.cp.noun())
+		r.touch(u.ref.pkg.Fset, u.ref.file)
+
+		for _, c := range r.calls[key] {
+			if u.index >= len(c.call.Args) {
+				continue
+			}
+			c.call.Args = slices.Delete(c.call.Args, u.index, u.index+1)
+			r.changes.note(c.caller, "drop %s argument of %s", r.cp.noun(), u.ref.fn.Name.Name)
+			r.touch(c.pkg.Fset, c.file)
+			callers[c.caller] = c
+		}
+	}
+	for _, c := range callers {
+		r.dropUnusedCtxVars(c.pkg, c.file, c.caller)
+	}
+}
+
+// dropUnusedCtxVars removes the context variables of fn that are no longer used, such as
+// ctx := context.Background() inserted at a boundary. A variable defined with other values, or
+// by an expression that may have side effects, is replaced with the blank identifier instead.
+func (r *remover) dropUnusedCtxVars(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl) {
+	info := pkg.TypesInfo
+	used := make(map[types.Object]bool)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] != nil {
+			used[info.Uses[id]] = true
+		}
+
+		return true
+	})
+	astutil.Apply(fn.Body, func(c *astutil.Cursor) bool {
+		assign, ok := c.Node().(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE {
+			return true
+		}
+		dropped := false
+		for _, lhs := range assign.Lhs {
+			id, ok := lhs.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if obj := info.Defs[id]; obj != nil && !used[obj] && r.cp.isContext(obj.Type()) {
+				r.changes.note(fn, "remove %s, no longer used", id.Name)
+				id.Name = "_"
+				dropped = true
+			}
+		}
+		if !dropped {
+			return true
+		}
+		if slices.ContainsFunc(assign.Lhs, func(e ast.Expr) bool { id, ok := e.(*ast.Ident); return !ok || id.Name != "_" }) {
+			return true
+		}
+		if len(assign.Rhs) == 1 && isPureCtxExpr(info, assign.Rhs[0]) {
+			r.removeStmt(pkg.Fset, file, c)
+
+			return true
+		}
+		assign.Tok = token.ASSIGN
+
+		return true
+	}, nil)
+}
+
+// isPureCtxExpr reports whether expr derives a context without side effects: context.Background(),
+// context.TODO() or x.Context(), as inserted at boundaries.
+func isPureCtxExpr(info *types.Info, expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+	f, ok := calledFunc(info, call)
+	if !ok {
+		return false
+	}
+	switch f.FullName() {
+	case "context.Background", "context.TODO":
+		return true
+	}
+	_, isSel := call.Fun.(*ast.SelectorExpr)
+
+	return isSel && f.Name() == "Context"
+}
@@ -0,0 +1,279 @@
+package goctx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/tools/go/packages"
+)
+
+// BoundaryRule makes every function with a parameter of type Type a boundary where ctx is derived
+// from that parameter with Expr, instead of being propagated to the function's callers.
+type BoundaryRule struct {
+	// Type is the parameter type, qualified by import path (*github.com/spf13/cobra.Command) or
+	// by package name (echo.Context).
+	Type string `json:"type" toml:"type" yaml:"type"`
+	// Expr is a text/template producing the context expression; {{.Param}} is the parameter
+	// name, e.g. {{.Param}}.Request.Context().
+	Expr string `json:"expr" toml:"expr" yaml:"expr"`
+	// Params optionally restricts the rule to functions whose parameter types are exactly these,
+	// in order, e.g. *github.com/spf13/cobra.Command and []string for cobra's RunE.
+	Params []string `json:"params,omitempty" toml:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// cliBoundaryRules are the boundaries enabled by Options.CLI besides urfave/cli v3 actions,
+// which already receive a context.Context.
+var cliBoundaryRules = []BoundaryRule{
+	{
+		Type:   "*github.com/spf13/cobra.Command",
+		Expr:   "{{.Param}}.Context()",
+		Params: []string{"*github.com/spf13/cobra.Command", "[]string"},
+	},
+	{
+		Type:   "*github.com/urfave/cli/v2.Context",
+		Expr:   "{{.Param}}.Context",
+		Params: []string{"*github.com/urfave/cli/v2.Context"},
+	},
+}
+
+// webFrameworks maps the names accepted by Options.Web to the rules for their handlers. chi
+// handlers are plain net/http handlers, so naming chi only enables those.
+var webFrameworks = map[string][]BoundaryRule{
+	"chi": nil,
+	"echo": {{
+		Type:   "github.com/labstack/echo/v4.Context",
+		Expr:   "{{.Param}}.Request().Context()",
+		Params: []string{"github.com/labstack/echo/v4.Context"},
+	}},
+	"fiber": {{
+		Type:   "*github.com/gofiber/fiber/v2.Ctx",
+		Expr:   "{{.Param}}.UserContext()",
+		Params: []string{"*github.com/gofiber/fiber/v2.Ctx"},
+	}},
+	"gin": {{
+		Type:   "*github.com/gin-gonic/gin.Context",
+		Expr:   "{{.Param}}.Request.Context()",
+		Params: []string{"*github.com/gin-gonic/gin.Context"},
+	}},
+}
+
+// validateWeb checks that every name in web is a known framework.
+func validateWeb(web []string) error {
+	for _, name := range web {
+		if _, ok := webFrameworks[name]; !ok {
+			return fmt.Errorf("unknown web framework %q, want one of chi, echo, fiber, gin", name)
+		}
+	}
+
+	return nil
+}
+
+// webBoundaryRules returns the handler rules of the frameworks named in web.
+func webBoundaryRules(web []string) []BoundaryRule {
+	var rules []BoundaryRule
+	for _, name := range web {
+		rules = append(rules, webFrameworks[name]...)
+	}
+
+	return rules
+}
+
+// httpHandlers reports whether net/http handlers are boundaries.
+func (o Options) httpHandlers() bool {
+	return o.HTML || slices.Contains(o.Web, "chi")
+}
+
+// boundaryRules returns the user's rules followed by the built-in rules enabled by opts.
+func (o Options) boundaryRules() []BoundaryRule {
+	rules := append([]BoundaryRule(nil), o.BoundaryRules...)
+	if o.CLI {
+		rules = append(rules, cliBoundaryRules...)
+	}
+
+	return append(rules, webBoundaryRules(o.Web)...)
+}
+
+// boundaryRulesFile is the layout of a boundary rules file.
+type boundaryRulesFile struct {
+	Boundaries []BoundaryRule `json:"boundaries" yaml:"boundaries"`
+}
+
+// LoadBoundaryRules reads boundary rules from a YAML (or JSON) file of the form
+//
+//	boundaries:
+//	  - type: "*github.com/gin-gonic/gin.Context"
+//	    expr: "{{.Param}}.Request.Context()"
+func LoadBoundaryRules(path string) ([]BoundaryRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading boundary rules: %w", err)
+	}
+	var file boundaryRulesFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parsing boundary rules %s: %w", path, err)
+	}
+	if err := validateBoundaryRules(file.Boundaries); err != nil {
+		return nil, fmt.Errorf("in %s: %w", path, err)
+	}
+
+	return file.Boundaries, nil
+}
+
+// validateBoundaryRules checks that every rule names a type and renders a valid Go expression.
+func validateBoundaryRules(rules []BoundaryRule) error {
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.Type) == "" {
+			return fmt.Errorf("boundary rule %d: missing type", i+1)
+		}
+		if _, err := rule.derive("param"); err != nil {
+			return fmt.Errorf("boundary rule %d (%s): %w", i+1, rule.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// derive renders the rule's expression for a parameter named param.
+func (r BoundaryRule) derive(param string) (string, error) {
+	tmpl, err := template.New(r.Type).Parse(r.Expr)
+	if err != nil {
+		return "", fmt.Errorf("parsing expr template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Param string }{Param: param}); err != nil {
+		return "", fmt.Errorf("rendering expr template: %w", err)
+	}
+	if _, err := parser.ParseExpr(buf.String()); err != nil {
+		return "", fmt.Errorf("parsing rendered expr %q: %w", buf.String(), err)
+	}
+
+	return buf.String(), nil
+}
+
+// typeMatches reports whether t is the type named by want, qualified either way.
+func typeMatches(want string, t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	byPath := types.TypeString(t, func(p *types.Package) string { return p.Path() })
+	byName := types.TypeString(t, func(p *types.Package) string { return p.Name() })
+
+	return want == byPath || want == byName
+}
+
+// matchesParams reports whether the parameter types of fn are exactly r.Params, when set.
+func (r BoundaryRule) matchesParams(fn *ast.FuncDecl, pkg *packages.Package) bool {
+	if len(r.Params) == 0 {
+		return true
+	}
+	var params []types.Type
+	for _, field := range fn.Type.Params.List {
+		for range max(1, len(field.Names)) {
+			params = append(params, pkg.TypesInfo.TypeOf(field.Type))
+		}
+	}
+	if len(params) != len(r.Params) {
+		return false
+	}
+	for i, want := range r.Params {
+		if !typeMatches(want, params[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchBoundaryRule returns the first rule matching a parameter of fn, and that parameter.
+func matchBoundaryRule(fn *ast.FuncDecl, pkg *packages.Package, rules []BoundaryRule) (*BoundaryRule, *ast.Field) {
+	if fn == nil || fn.Type == nil || fn.Type.Params == nil {
+		return nil, nil
+	}
+	for i := range rules {
+		if !rules[i].matchesParams(fn, pkg) {
+			continue
+		}
+		for _, field := range fn.Type.Params.List {
+			if typeMatches(rules[i].Type, pkg.TypesInfo.TypeOf(field.Type)) {
+				return &rules[i], field
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// boundaryRuleReason reports whether fn is a boundary by one of the user's rules (StopReasonRule)
+// or by a built-in framework rule enabled by opts (StopReasonCLI, StopReasonHTTP).
+func boundaryRuleReason(fn *ast.FuncDecl, pkg *packages.Package, opts Options) (StopReason, bool) {
+	if rule, _ := matchBoundaryRule(fn, pkg, opts.BoundaryRules); rule != nil {
+		return StopReasonRule, true
+	}
+	if opts.CLI {
+		if rule, _ := matchBoundaryRule(fn, pkg, cliBoundaryRules); rule != nil || isCLIV3Action(fn, pkg) {
+			return StopReasonCLI, true
+		}
+	}
+	if rule, _ := matchBoundaryRule(fn, pkg, webBoundaryRules(opts.Web)); rule != nil {
+		return StopReasonHTTP, true
+	}
+
+	return StopReasonNone, false
+}
+
+// isCLIV3Action reports whether fn has the signature of a urfave/cli v3 action,
+// func(context.Context, *cli.Command) error.
+func isCLIV3Action(fn *ast.FuncDecl, pkg *packages.Package) bool {
+	return BoundaryRule{Params: []string{ContextContext, "*github.com/urfave/cli/v3.Command"}}.matchesParams(fn, pkg)
+}
+
+// nameCLIV3CtxParam gives the context parameter of a urfave/cli v3 action a usable name without
+// changing the action's signature.
+func nameCLIV3CtxParam(fn *ast.FuncDecl, name string) {
+	first := fn.Type.Params.List[0]
+	switch {
+	case len(first.Names) == 0:
+		// All parameters are unnamed: name them all, as Go requires.
+		first.Names = []*ast.Ident{ast.NewIdent(name)}
+		for _, field := range fn.Type.Params.List[1:] {
+			field.Names = []*ast.Ident{ast.NewIdent("_")}
+		}
+	case first.Names[0].Name == "_":
+		first.Names[0] = ast.NewIdent(name)
+	}
+}
+
+// makeAssignCtxFromRule builds <name> := <expr> for the rule matching fn, and returns the rendered
+// expression.
+func makeAssignCtxFromRule(fn *ast.FuncDecl, pkg *packages.Package, rules []BoundaryRule, name string) (ast.Stmt, string, error) {
+	rule, field := matchBoundaryRule(fn, pkg, rules)
+	if rule == nil {
+		return nil, "", errors.New("no boundary rule matches")
+	}
+	if len(field.Names) == 0 || field.Names[0].Name == "_" {
+		return nil, "", fmt.Errorf("deriving ctx in %s: its %s parameter has no name", fn.Name.Name, rule.Type)
+	}
+	expr, err := rule.derive(field.Names[0].Name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Like placeholder contexts passed to calls, the rendered expression is printed verbatim.
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(name)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{ast.NewIdent(expr)},
+	}, expr, nil
+}
@@ -0,0 +1,38 @@
+package goctx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBoundaryRules(t *testing.T) {
+	t.Parallel()
+
+	write := func(t *testing.T, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "rules.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		return path
+	}
+
+	rules, err := LoadBoundaryRules(write(t, `{"boundaries": [{"type": "echo.Context", "expr": "{{.Param}}.Request().Context()"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, []BoundaryRule{{Type: "echo.Context", Expr: "{{.Param}}.Request().Context()"}}, rules)
+
+	expr, err := rules[0].derive("c")
+	require.NoError(t, err)
+	require.Equal(t, "c.Request().Context()", expr)
+
+	_, err = LoadBoundaryRules(write(t, "boundaries:\n  - type: echo.Context\n    exp: x\n"))
+	require.ErrorContains(t, err, "field exp not found")
+
+	_, err = LoadBoundaryRules(write(t, "boundaries:\n  - type: echo.Context\n    expr: \"{{.Param}}.Context(\"\n"))
+	require.ErrorContains(t, err, "boundary rule 1 (echo.Context): parsing rendered expr")
+
+	_, err = LoadBoundaryRules(write(t, "boundaries:\n  - expr: ctx\n"))
+	require.ErrorContains(t, err, "boundary rule 1: missing type")
+}
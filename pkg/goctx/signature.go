@@ -2,6 +2,7 @@ package goctx
 
 import (
 	"go/ast"
+	"go/parser"
 	"go/token"
 	"go/types"
 	"sort"
@@ -10,13 +11,13 @@ import (
 	"golang.org/x/tools/go/ast/astutil"
 )
 
-// ensureFuncHasCtxParam ensures the function has a usable context.Context parameter.
+// ensureFuncHasCtxParam ensures the function has a usable context parameter.
 // Behavior:
-// - If there's a parameter of type context.Context named "_", rename it to "ctx" when renameBlank is true.
-// - If there's any parameter of type context.Context with a different usable name, do nothing.
-// - Otherwise, add a new first parameter "ctx context.Context".
+// - If there's a parameter of a context type (see isContextType) named "_", rename it to cp.name when renameBlank is true.
+// - If there's any parameter of a context type with a different usable name, do nothing.
+// - Otherwise, add a new first parameter "ctx context.Context" (cp.name of the type of cp).
 // Returns true if the signature was modified.
-func ensureFuncHasCtxParam(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl, info *types.Info, renameBlank bool) bool {
+func ensureFuncHasCtxParam(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl, info *types.Info, cp ctxParam, renameBlank bool) bool {
 	// Fast paths and guards
 	if fn == nil || fn.Type == nil {
 		return false
@@ -24,26 +25,28 @@ func ensureFuncHasCtxParam(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl
 	params := fn.Type.Params
 	if params == nil {
 		// No params at all: we will add one below
-		return addCtxParamAsFirst(fset, file, fn)
+		return addCtxParamAsFirst(fset, file, fn, info, cp)
 	}
 
 	// If a parameter explicitly named ctx already exists, do not add another
-	if hasParamNamedCtx(params) {
+	if hasParamNamedCtx(params, cp.name) {
 		return false
 	}
 
 	// Look for any context.Context parameter in existing list
 	for _, field := range params.List {
-		if field == nil || field.Type == nil || !isContextType(info, field.Type) {
+		if field == nil || field.Type == nil || !isContextType(info, field.Type, cp) {
 			continue
 		}
 		// Found a context parameter
 		if len(field.Names) > 0 {
 			// It's named
 			if field.Names[0].Name == "_" && renameBlank {
-				field.Names[0].Name = VarNameCtx
+				field.Names[0].Name = cp.name
 				field.Names[0].NamePos = token.NoPos
-				ensureImport(fset, file, "context")
+				if cp.typ == nil {
+					ensureImport(fset, file, "context")
+				}
 
 				return true
 			}
@@ -51,22 +54,22 @@ func ensureFuncHasCtxParam(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl
 			return false
 		}
 		// Unnamed parameter of the right type: we can't reference it; conservatively add a named one in front.
-		return addCtxParamAsFirst(fset, file, fn)
+		return addCtxParamAsFirst(fset, file, fn, info, cp)
 	}
 
 	// No suitable existing parameter found: add a new one.
 
-	return addCtxParamAsFirst(fset, file, fn)
+	return addCtxParamAsFirst(fset, file, fn, info, cp)
 }
 
-// hasParamNamedCtx reports whether any parameter is named ctx.
-func hasParamNamedCtx(params *ast.FieldList) bool {
+// hasParamNamedCtx reports whether any parameter is named name.
+func hasParamNamedCtx(params *ast.FieldList, name string) bool {
 	if params == nil {
 		return false
 	}
 	for _, field := range params.List {
 		for _, nm := range field.Names {
-			if nm != nil && nm.Name == VarNameCtx {
+			if nm != nil && nm.Name == name {
 				return true
 			}
 		}
@@ -75,21 +78,20 @@ func hasParamNamedCtx(params *ast.FieldList) bool {
 	return false
 }
 
-// isContextType reports whether expr denotes context.Context, using types.Info when available
-// and falling back to a direct AST selector check.
-func isContextType(info *types.Info, expr ast.Expr) bool {
+// isContextType reports whether expr denotes a type whose values cp accepts (see
+// ctxParam.accepts), using types.Info when available and falling back to a direct AST selector
+// check.
+func isContextType(info *types.Info, expr ast.Expr, cp ctxParam) bool {
 	if expr == nil {
 		return false
 	}
 	if info != nil {
-		if t := info.TypeOf(expr); t != nil {
-			if types.TypeString(t, func(p *types.Package) string { return p.Path() }) == ContextContext {
-				return true
-			}
+		if t := info.TypeOf(expr); t != nil && cp.accepts(t) {
+			return true
 		}
 	}
 	// Fallback: check for selector expression context.Context
-	if sel, ok := expr.(*ast.SelectorExpr); ok {
+	if sel, ok := expr.(*ast.SelectorExpr); ok && !cp.narrow {
 		if xid, ok := sel.X.(*ast.Ident); ok && xid.Name == "context" && sel.Sel != nil && sel.Sel.Name == "Context" {
 			return true
 		}
@@ -98,12 +100,22 @@ func isContextType(info *types.Info, expr ast.Expr) bool {
 	return false
 }
 
-// addCtxParamAsFirst inserts a new first parameter "ctx context.Context" and normalizes positions.
-func addCtxParamAsFirst(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl) bool {
-	ensureImport(fset, file, "context")
+// addCtxParamAsFirst inserts a new first parameter "ctx context.Context", named and typed as cp
+// says, and normalizes positions.
+func addCtxParamAsFirst(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl, info *types.Info, cp ctxParam) bool {
+	pkgPath := ""
+	if info != nil {
+		if obj := info.Defs[fn.Name]; obj != nil && obj.Pkg() != nil {
+			pkgPath = obj.Pkg().Path()
+		}
+	}
+	typ, imp := cp.typeExpr(file, pkgPath)
+	if imp != "" {
+		ensureImport(fset, file, imp)
+	}
 	ctxField := &ast.Field{
-		Names: []*ast.Ident{ast.NewIdent(VarNameCtx)},
-		Type:  &ast.SelectorExpr{X: ast.NewIdent("context"), Sel: ast.NewIdent("Context")},
+		Names: []*ast.Ident{ast.NewIdent(cp.name)},
+		Type:  typ,
 	}
 	if fn.Type.Params == nil {
 		fn.Type.Params = &ast.FieldList{List: []*ast.Field{ctxField}}
@@ -122,6 +134,66 @@ func addCtxParamAsFirst(fset *token.FileSet, file *ast.File, fn *ast.FuncDecl) b
 	return true
 }
 
+// assignLocalCtx turns the short variable declarations of cp.name at the top level of fn.Body,
+// which would redeclare the context parameter just added to fn, into assignments. Declarations of
+// other new variables alongside it stay valid and are left alone.
+func assignLocalCtx(fn *ast.FuncDecl, info *types.Info, cp ctxParam) {
+	if fn.Body == nil || info == nil {
+		return
+	}
+	for _, stmt := range fn.Body.List {
+		as, ok := stmt.(*ast.AssignStmt)
+		if !ok || as.Tok != token.DEFINE {
+			continue
+		}
+		declaresCtx, declaresOthers := false, false
+		for _, lhs := range as.Lhs {
+			id, ok := lhs.(*ast.Ident)
+			if !ok || info.Defs[id] == nil {
+				continue
+			}
+			if id.Name == cp.name && cp.accepts(info.Defs[id].Type()) {
+				declaresCtx = true
+			} else {
+				declaresOthers = true
+			}
+		}
+		if declaresCtx && !declaresOthers {
+			as.Tok = token.ASSIGN
+		}
+	}
+}
+
+// ensureContextImportFor adds the context import to file when expr, Go source printed verbatim
+// such as a rule expression or a placeholder, refers to the context package: context.TODO() does,
+// appcontext.Root() does not.
+func ensureContextImportFor(fset *token.FileSet, file *ast.File, expr string) {
+	if refersToPackage(expr, "context") {
+		ensureImport(fset, file, "context")
+	}
+}
+
+// refersToPackage reports whether the Go expression src selects a member of the package imported
+// as name, e.g. name.Func(). Source that does not parse refers to none.
+func refersToPackage(src, name string) bool {
+	expr, err := parser.ParseExpr(src)
+	if err != nil {
+		return false
+	}
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name {
+				found = true
+			}
+		}
+
+		return !found
+	})
+
+	return found
+}
+
 func ensureImport(fset *token.FileSet, file *ast.File, path string) {
 	if file == nil {
 		return
@@ -28,12 +28,30 @@ func TestEnsureImport_AddsOnceAndSorts(t *testing.T) {
 	assert.Equal(t, []string{"\"context\"", "\"fmt\""}, imports)
 }
 
+func TestEnsureContextImportFor(t *testing.T) {
+	for expr, want := range map[string]bool{
+		"context.TODO()":                   true,
+		"appctx.New(context.Background())": true,
+		"appcontext.Root()":                false,
+		"mycontext.TODO()":                 false,
+		"ctx.Value(key)":                   false,
+		"not an expression (":              false,
+	} {
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, "f.go", "package p\n", parser.ParseComments)
+		require.NoError(t, err)
+
+		ensureContextImportFor(fset, astFile, expr)
+		assert.Equal(t, want, len(astFile.Imports) == 1, expr)
+	}
+}
+
 func TestEnsureFuncHasCtxParam_AddsParam(t *testing.T) {
 	fset := token.NewFileSet()
 	f := &ast.File{}
 	fn := &ast.FuncDecl{Type: &ast.FuncType{Params: &ast.FieldList{}}}
 	// info can be nil; function should still add a ctx param conservatively
-	ensureFuncHasCtxParam(fset, f, fn, nil, false)
+	ensureFuncHasCtxParam(fset, f, fn, nil, defaultCtxParam, false)
 	assert.NotNil(t, fn.Type.Params)
 	assert.NotEmpty(t, fn.Type.Params.List)
 
@@ -0,0 +1,118 @@
+package goctx
+
+import (
+	"context"
+	"go/ast"
+	"go/types"
+	"log/slog"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultSlogUpgrades are the upgrades of the log/slog functions and *slog.Logger methods that
+// log at a fixed level to their Context variants, e.g. slog.Info to slog.InfoContext.
+var DefaultSlogUpgrades = defaultSlogUpgrades()
+
+func defaultSlogUpgrades() []StdlibUpgrade {
+	var upgrades []StdlibUpgrade
+	for _, level := range []string{"Debug", "Info", "Warn", "Error"} {
+		upgrades = append(upgrades,
+			StdlibUpgrade{Func: "log/slog." + level, Expr: "{{.Pkg}}." + level + "Context({{.Ctx}}, {{.Args}})"},
+			StdlibUpgrade{Func: "(*log/slog.Logger)." + level, Expr: "{{.Recv}}." + level + "Context({{.Ctx}}, {{.Args}})"},
+		)
+	}
+
+	return upgrades
+}
+
+// UpgradeSlog plans rewriting slog.Info, (*slog.Logger).Error and the other level functions to
+// their Context variants in every function of the packages matching patterns (all packages of
+// the module when patterns is empty) that has a context in scope.
+//
+// With Options.PropagateToLoggers, the functions of those packages that log without a context in
+// scope get one first: they are targets, whose context is propagated to their callers as by Plan,
+// except for boundaries such as main or HTTP handlers, which derive it.
+func UpgradeSlog(ctx context.Context, opts Options, patterns []string) (*ChangePlan, error) {
+	opts.UpgradeSlog = true
+	if !opts.PropagateToLoggers {
+		return upgradeCallsIn(ctx, opts, patterns)
+	}
+	if err := validatePlanOptions(opts); err != nil {
+		return nil, err
+	}
+	workDir := firstNonEmpty(opts.WorkDir, ".")
+	pkgs, err := loadAllPackages(ctx, workDir, opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := selectPackages(ctx, workDir, opts.Tags, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return planPackages(ctx, opts, pkgs, selected)
+}
+
+// loggingFuncs returns the functions of the packages in selected (all when nil) that call a
+// function of DefaultSlogUpgrades where no context is in scope, e.g. before they declare one,
+// skipping the files filter excludes.
+// init functions, which cannot take parameters, are skipped too.
+func loggingFuncs(pkgs []*packages.Package, selected map[string]bool, filter *fileFilter, cp ctxParam) []*targetResolution {
+	logFuncs := make(map[string]bool)
+	for _, up := range DefaultSlogUpgrades {
+		logFuncs[up.Func] = true
+	}
+	var loggers []*targetResolution
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		if selected != nil && !selected[pkg.PkgPath] {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			if seen[file] || !filter.allows(pkg.Fset.File(file.Pos()).Name()) {
+				continue
+			}
+			seen[file] = true
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil || (fn.Recv == nil && fn.Name.Name == "init") {
+					continue
+				}
+				if !logsWithoutCtx(pkg, fn, logFuncs, cp) {
+					continue
+				}
+				slog.Debug("logging function without ctx", slog.String("func", funcKeyOfDecl(pkg, fn)))
+				loggers = append(loggers, &targetResolution{
+					Pkg:     pkg,
+					FileAST: file,
+					Fset:    pkg.Fset,
+					Info:    pkg.TypesInfo,
+					Decl:    fn,
+					Obj:     pkg.TypesInfo.Defs[fn.Name],
+				})
+			}
+		}
+	}
+
+	return loggers
+}
+
+// logsWithoutCtx reports whether fn calls one of the functions named in logFuncs where no context
+// is in scope.
+func logsWithoutCtx(pkg *packages.Package, fn *ast.FuncDecl, logFuncs map[string]bool, cp ctxParam) bool {
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found {
+			return !found
+		}
+		obj, _ := resolveCalled(pkg.TypesInfo, call.Fun)
+		if f, ok := obj.(*types.Func); ok && logFuncs[f.FullName()] && ctxIdentAt(fn, pkg, cp, call.Pos()) == "" {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
@@ -0,0 +1,407 @@
+package goctx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// StdlibUpgrade maps a function or method that does not take a context to its context-aware
+// variant.
+type StdlibUpgrade struct {
+	// Func is the full name of the function: net/http.NewRequest or (*database/sql.DB).Query.
+	Func string `json:"func" toml:"func" yaml:"func"`
+	// Expr is a text/template of the replacement call. {{.Ctx}} is the context in scope,
+	// {{.Recv}} the receiver of a method call, {{.Pkg}} the package qualifier of a function call
+	// and {{.Args}} the arguments, comma-separated ({{index .Args 0}} selects one).
+	Expr string `json:"expr,omitempty" toml:"expr,omitempty" yaml:"expr,omitempty"`
+	// Hint is reported for calls to Func instead of rewriting them, when there is no
+	// single-expression replacement. Exactly one of Expr and Hint is set.
+	Hint string `json:"hint,omitempty" toml:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+const (
+	hintHTTPRequest = "build the request with http.NewRequestWithContext and send it with (*http.Client).Do"
+	exprSQLContext  = "{{.Recv}}.%sContext({{.Ctx}}, {{.Args}})"
+)
+
+// DefaultStdlibUpgrades are the upgrades of net/http, database/sql, os/exec, net and os/signal
+// calls. Options.StdlibUpgrades and the stdlib-upgrades configuration key take precedence.
+var DefaultStdlibUpgrades = defaultStdlibUpgrades()
+
+func defaultStdlibUpgrades() []StdlibUpgrade {
+	upgrades := []StdlibUpgrade{
+		{Func: "net/http.NewRequest", Expr: "{{.Pkg}}.NewRequestWithContext({{.Ctx}}, {{.Args}})"},
+		{Func: "(*database/sql.DB).Begin", Expr: "{{.Recv}}.BeginTx({{.Ctx}}, nil)"},
+		{Func: "os/exec.Command", Expr: "{{.Pkg}}.CommandContext({{.Ctx}}, {{.Args}})"},
+		{Func: "net.Dial", Expr: "(&{{.Pkg}}.Dialer{}).DialContext({{.Ctx}}, {{.Args}})"},
+		{Func: "net.DialTimeout", Expr: "(&{{.Pkg}}.Dialer{Timeout: {{index .Args 2}}}).DialContext({{.Ctx}}, {{index .Args 0}}, {{index .Args 1}})"},
+		{Func: "(*net.Dialer).Dial", Expr: "{{.Recv}}.DialContext({{.Ctx}}, {{.Args}})"},
+		{Func: "net.Listen", Expr: "(&{{.Pkg}}.ListenConfig{}).Listen({{.Ctx}}, {{.Args}})"},
+		{Func: "net.LookupIP", Expr: `{{.Pkg}}.DefaultResolver.LookupIP({{.Ctx}}, "ip", {{.Args}})`},
+		{Func: "os/signal.Notify", Hint: "derive a context cancelled on the signals with signal.NotifyContext"},
+	}
+	for _, name := range []string{"Get", "Head", "Post", "PostForm"} {
+		upgrades = append(upgrades,
+			StdlibUpgrade{Func: "net/http." + name, Hint: hintHTTPRequest},
+			StdlibUpgrade{Func: "(*net/http.Client)." + name, Hint: hintHTTPRequest},
+		)
+	}
+	for _, name := range []string{"Query", "QueryRow", "Exec", "Prepare", "Ping"} {
+		upgrades = append(upgrades, StdlibUpgrade{Func: "(*database/sql.DB)." + name, Expr: fmt.Sprintf(exprSQLContext, name)})
+	}
+	for _, name := range []string{"Query", "QueryRow", "Exec", "Prepare", "Stmt"} {
+		upgrades = append(upgrades, StdlibUpgrade{Func: "(*database/sql.Tx)." + name, Expr: fmt.Sprintf(exprSQLContext, name)})
+	}
+	for _, name := range []string{"Query", "QueryRow", "Exec"} {
+		upgrades = append(upgrades, StdlibUpgrade{Func: "(*database/sql.Stmt)." + name, Expr: fmt.Sprintf(exprSQLContext, name)})
+	}
+	for _, name := range []string{"LookupAddr", "LookupCNAME", "LookupHost", "LookupMX", "LookupNS", "LookupPort", "LookupSRV", "LookupTXT"} {
+		upgrades = append(upgrades, StdlibUpgrade{Func: "net." + name, Expr: "{{.Pkg}}.DefaultResolver." + name + "({{.Ctx}}, {{.Args}})"})
+	}
+
+	return upgrades
+}
+
+// ManualUpgrade is a call to a function without a single-expression context-aware variant,
+// reported instead of rewritten.
+type ManualUpgrade struct {
+	Pos      token.Position // the call site
+	Function string         // fully qualified function containing the call
+	Callee   string         // the function called, e.g. net/http.Get
+	Hint     string         // how to pass the context instead
+}
+
+func (m ManualUpgrade) String() string {
+	return fmt.Sprintf("%s: %s calls %s, which takes no context; %s", m.Pos, m.Function, m.Callee, m.Hint)
+}
+
+// upgradeData is the data of StdlibUpgrade.Expr templates.
+type upgradeData struct {
+	Ctx  string
+	Recv string
+	Pkg  string
+	Args callArgs
+}
+
+// callArgs renders as the comma-separated arguments of a call.
+type callArgs []string
+
+func (a callArgs) String() string {
+	return strings.Join(a, ", ")
+}
+
+// stdlibUpgrader rewrites calls according to a table of upgrades, and calls of functions with a
+// context-aware sibling to the sibling.
+type stdlibUpgrader struct {
+	byFunc   map[string]StdlibUpgrade
+	tmpls    map[string]*template.Template
+	variants map[string]contextVariant
+}
+
+// newStdlibUpgrader indexes extra, then the tables, by function; the first entry for a function
+// wins.
+func newStdlibUpgrader(extra []StdlibUpgrade, tables ...[]StdlibUpgrade) (*stdlibUpgrader, error) {
+	u := &stdlibUpgrader{byFunc: make(map[string]StdlibUpgrade), tmpls: make(map[string]*template.Template)}
+	all := append([]StdlibUpgrade(nil), extra...)
+	for _, table := range tables {
+		all = append(all, table...)
+	}
+	for i, up := range all {
+		if _, ok := u.byFunc[up.Func]; ok {
+			continue
+		}
+		switch {
+		case strings.TrimSpace(up.Func) == "":
+			return nil, fmt.Errorf("stdlib upgrade %d: missing func", i+1)
+		case (up.Expr == "") == (up.Hint == ""):
+			return nil, fmt.Errorf("stdlib upgrade %d (%s): want exactly one of expr and hint", i+1, up.Func)
+		}
+		u.byFunc[up.Func] = up
+		if up.Expr == "" {
+			continue
+		}
+		tmpl, err := template.New(up.Func).Option("missingkey=error").Parse(up.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("stdlib upgrade %d (%s): parsing expr: %w", i+1, up.Func, err)
+		}
+		u.tmpls[up.Func] = tmpl
+		if _, err := u.render(up.Func, upgradeData{Ctx: "ctx", Recv: "r", Pkg: "p", Args: callArgs{"a", "b", "c", "d"}}); err != nil {
+			return nil, fmt.Errorf("stdlib upgrade %d (%s): %w", i+1, up.Func, err)
+		}
+	}
+
+	return u, nil
+}
+
+// validateStdlibUpgrades checks the templates and hints of upgrades.
+func validateStdlibUpgrades(upgrades []StdlibUpgrade) error {
+	_, err := newStdlibUpgrader(upgrades)
+
+	return err
+}
+
+// render executes the template of fn and returns the replacement call in gofmt style.
+func (u *stdlibUpgrader) render(fn string, data upgradeData) (string, error) {
+	var buf bytes.Buffer
+	if err := u.tmpls[fn].Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering expr: %w", err)
+	}
+	expr, err := parser.ParseExpr(buf.String())
+	if err != nil {
+		return "", fmt.Errorf("expr %q is not a Go expression: %w", buf.String(), err)
+	}
+	var out bytes.Buffer
+	if err := format.Node(&out, token.NewFileSet(), expr); err != nil {
+		return "", fmt.Errorf("formatting expr: %w", err)
+	}
+
+	return out.String(), nil
+}
+
+// hintNoCtxAtCall is the hint of the calls that have a context-aware variant but are made where
+// no context is in scope, such as before the context of the function is declared.
+const hintNoCtxAtCall = "declare a context before the call to use its context-aware variant"
+
+// upgradeCalls rewrites the calls in fn to their context-aware variants, passing the context in
+// scope at each call, and records calls that have no such variant or no context in scope. It
+// reports whether fn was modified.
+func (u *stdlibUpgrader) upgradeCalls(pkg *packages.Package, fn *ast.FuncDecl, cp ctxParam, changes *changeLog) (bool, error) {
+	if fn.Body == nil {
+		return false, nil
+	}
+	if !hasCtxInScope(fn, pkg, cp) {
+		return false, nil
+	}
+	var (
+		modified bool
+		err      error
+	)
+	astutil.Apply(fn.Body, nil, func(c *astutil.Cursor) bool {
+		call, ok := c.Node().(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		obj, _ := resolveCalled(pkg.TypesInfo, call.Fun)
+		f, ok := obj.(*types.Func)
+		if !ok {
+			return true
+		}
+		variant, isVariant := u.variants[f.FullName()]
+		up, ok := u.byFunc[f.FullName()]
+		if !isVariant && !ok {
+			return true
+		}
+		if !isVariant && up.Expr == "" {
+			changes.addManual(ManualUpgrade{
+				Pos:      pkg.Fset.Position(call.Pos()),
+				Function: funcKeyOfDecl(pkg, fn),
+				Callee:   f.FullName(),
+				Hint:     up.Hint,
+			})
+
+			return true
+		}
+		callCtx := ctxIdentAt(fn, pkg, cp, call.Pos())
+		if callCtx == "" {
+			changes.addManual(ManualUpgrade{
+				Pos:      pkg.Fset.Position(call.Pos()),
+				Function: funcKeyOfDecl(pkg, fn),
+				Callee:   f.FullName(),
+				Hint:     hintNoCtxAtCall,
+			})
+
+			return true
+		}
+		if isVariant {
+			if useContextVariant(pkg, fn, call, f, variant, callCtx, changes) {
+				modified = true
+			}
+
+			return true
+		}
+		data, ok := upgradeCallData(pkg, call, callCtx)
+		if !ok {
+			return true
+		}
+		var replacement string
+		if replacement, err = u.render(up.Func, data); err != nil {
+			err = fmt.Errorf("upgrading call to %s at %s: %w", up.Func, pkg.Fset.Position(call.Pos()), err)
+
+			return false
+		}
+		c.Replace(&ast.Ident{NamePos: call.Pos(), Name: replacement})
+		changes.note(fn, "use the context-aware variant of %s", f.Name())
+		modified = true
+
+		return true
+	})
+
+	return modified, err
+}
+
+// upgradeCallData returns the template data of call, or false when the call is not written as
+// pkg.Func(...) or recv.Method(...). A variadic argument forwarded with ... keeps its ellipsis.
+func upgradeCallData(pkg *packages.Package, call *ast.CallExpr, ctxName string) (upgradeData, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return upgradeData{}, false
+	}
+	data := upgradeData{Ctx: ctxName}
+	if id, ok := sel.X.(*ast.Ident); ok {
+		if _, isPkg := pkg.TypesInfo.Uses[id].(*types.PkgName); isPkg {
+			data.Pkg = id.Name
+		}
+	}
+	if data.Pkg == "" {
+		data.Recv = nodeSource(pkg.Fset, sel.X)
+	}
+	for _, arg := range call.Args {
+		data.Args = append(data.Args, nodeSource(pkg.Fset, arg))
+	}
+	if call.Ellipsis.IsValid() && len(data.Args) > 0 {
+		data.Args[len(data.Args)-1] += "..."
+	}
+
+	return data, true
+}
+
+// UpgradeStdlib plans rewriting calls such as http.NewRequest, (*sql.DB).Query or exec.Command
+// to their context-aware variants in every function of the packages matching patterns (all
+// packages of the module when patterns is empty) that has a context in scope. Calls without a
+// single-expression variant are listed in ChangePlan.Manual. With Options.ContextVariants, calls
+// of functions of the module with a context-aware sibling are switched to the sibling too.
+func UpgradeStdlib(ctx context.Context, opts Options, patterns []string) (*ChangePlan, error) {
+	opts.UpgradeStdlib = true
+
+	return upgradeCallsIn(ctx, opts, patterns)
+}
+
+// upgradeCallsIn rewrites the calls of the tables opts selects in the packages matching patterns.
+func upgradeCallsIn(ctx context.Context, opts Options, patterns []string) (*ChangePlan, error) {
+	if opts.Param != nil {
+		return nil, errors.New("stdlib and slog upgrades apply to context parameters only")
+	}
+	upgrader, err := newStdlibUpgrader(opts.StdlibUpgrades, opts.upgradeTables()...)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newFileFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	workDir := firstNonEmpty(opts.WorkDir, ".")
+	pkgs, err := loadAllPackages(ctx, workDir, opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := resolveCtxParam(opts, pkgs)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ContextVariants {
+		upgrader.variants = contextVariants(pkgs, cp)
+	}
+	selected, err := selectPackages(ctx, workDir, opts.Tags, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiedFiles := make(map[string]bool)
+	changes := newChangeLog()
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		if selected != nil && !selected[pkg.PkgPath] {
+			continue
+		}
+		if err := interrupted(ctx, "upgrading calls"); err != nil {
+			return nil, err
+		}
+		for _, file := range pkg.Syntax {
+			if seen[file] || !filter.allows(pkg.Fset.File(file.Pos()).Name()) {
+				continue
+			}
+			seen[file] = true
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				modified, err := upgrader.upgradeCalls(pkg, fn, cp, changes)
+				if err != nil {
+					return nil, err
+				}
+				if modified {
+					markFileModified(modifiedFiles, pkg.Fset, file)
+				}
+			}
+		}
+	}
+
+	plan, err := buildPlan(pkgs, modifiedFiles, changes)
+	if err != nil {
+		return nil, fmt.Errorf("planning edits: %w", err)
+	}
+	slog.Debug("upgrade calls done", slog.Int("edits", len(plan.Edits)), slog.Int("manual", len(plan.Manual)))
+
+	return plan, nil
+}
+
+// upgradeTables returns the default upgrade tables selected by UpgradeStdlib and UpgradeSlog.
+func (o Options) upgradeTables() [][]StdlibUpgrade {
+	var tables [][]StdlibUpgrade
+	if o.UpgradeStdlib {
+		tables = append(tables, DefaultStdlibUpgrades)
+	}
+	if o.UpgradeSlog {
+		tables = append(tables, DefaultSlogUpgrades)
+	}
+
+	return tables
+}
+
+// upgradeModifiedFuncs applies Options.UpgradeStdlib, Options.UpgradeSlog and
+// Options.ContextVariants to the functions propagation modified.
+func upgradeModifiedFuncs(pkgs []*packages.Package, opts Options, cp ctxParam, modifiedFiles map[string]bool, changes *changeLog) error {
+	upgrader, err := newStdlibUpgrader(opts.StdlibUpgrades, opts.upgradeTables()...)
+	if err != nil {
+		return err
+	}
+	if opts.ContextVariants {
+		upgrader.variants = contextVariants(pkgs, cp)
+	}
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && len(changes.reasons[fn]) > 0 {
+					modified, err := upgrader.upgradeCalls(pkg, fn, cp, changes)
+					if err != nil {
+						return err
+					}
+					if modified {
+						markFileModified(modifiedFiles, pkg.Fset, file)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
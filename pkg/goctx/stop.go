@@ -0,0 +1,155 @@
+package goctx
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yaklabco/stave/pkg/fsutils"
+	"golang.org/x/tools/go/packages"
+)
+
+// StopAtRegexPrefix introduces a stop-at boundary given as a regular expression over fully
+// qualified function names, e.g. re:^\(\*example\.com/app/api\.Server\)\.[A-Z].
+const StopAtRegexPrefix = "re:"
+
+// stopSet is the set of --stop-at boundaries. The zero value matches nothing.
+type stopSet struct {
+	specs []stopSpec
+}
+
+// stopSpec is a single --stop-at boundary: a target spec, a package pattern, or a regular
+// expression over fully qualified function names.
+type stopSpec struct {
+	raw string
+
+	target *targetSpec
+
+	dir        string // absolute directory of a directory pattern (./internal/api[/...])
+	importPath string // import path of an import-path pattern (example.com/app/api/...)
+	recursive  bool   // pattern ends in /...
+
+	re *regexp.Regexp
+}
+
+// stopAtSpecs returns StopAt followed by StopAts, skipping blank entries.
+func (o Options) stopAtSpecs() []string {
+	var out []string
+	for _, s := range append([]string{o.StopAt}, o.StopAts...) {
+		if strings.TrimSpace(s) != "" {
+			out = append(out, strings.TrimSpace(s))
+		}
+	}
+
+	return out
+}
+
+// parseStopSpecs parses the stop-at boundaries of opts. Directory patterns are resolved against
+// the current working directory, like target files.
+func parseStopSpecs(opts Options) (*stopSet, error) {
+	set := &stopSet{}
+	for _, raw := range opts.stopAtSpecs() {
+		spec, err := parseStopSpec(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing stop-at %s: %w", raw, err)
+		}
+		set.specs = append(set.specs, spec)
+	}
+
+	return set, nil
+}
+
+func parseStopSpec(raw string) (stopSpec, error) {
+	spec := stopSpec{raw: raw}
+	switch {
+	case strings.HasPrefix(raw, StopAtRegexPrefix):
+		re, err := regexp.Compile(strings.TrimPrefix(raw, StopAtRegexPrefix))
+		if err != nil {
+			return stopSpec{}, fmt.Errorf("compiling regular expression: %w", err)
+		}
+		spec.re = re
+	case isDirPattern(raw):
+		dir, recursive := strings.CutSuffix(filepath.ToSlash(raw), "/...")
+		abs, err := fsutils.TruePath(filepath.FromSlash(dir))
+		if err != nil {
+			return stopSpec{}, fmt.Errorf("ascertaining true path: %w", err)
+		}
+		spec.dir, spec.recursive = abs, recursive
+	case strings.HasSuffix(raw, "/...") && !strings.Contains(raw, ":"):
+		spec.importPath, spec.recursive = strings.TrimSuffix(raw, "/..."), true
+	default:
+		target, err := parseTargetSpec(raw)
+		if err != nil {
+			return stopSpec{}, err
+		}
+		spec.target = &target
+	}
+
+	return spec, nil
+}
+
+// isDirPattern reports whether raw names a directory (optionally followed by /...) rather than
+// a function: it is "." or starts with ./, ../ or /, and has no function part after a colon.
+func isDirPattern(raw string) bool {
+	slashed := filepath.ToSlash(strings.TrimPrefix(raw, filepath.VolumeName(raw)))
+	if strings.Contains(slashed, ":") || strings.HasSuffix(slashed, ".go") {
+		return false
+	}
+
+	return slashed == "." || slashed == ".." || strings.HasPrefix(slashed, "./") || strings.HasPrefix(slashed, "../") || filepath.IsAbs(raw)
+}
+
+// matches reports whether fn, declared in pkg, is one of the boundaries, and which one.
+func (s *stopSet) matches(pkg *packages.Package, fn *ast.FuncDecl) (string, bool, error) {
+	if s == nil {
+		return "", false, nil
+	}
+	for _, spec := range s.specs {
+		ok, err := spec.matches(pkg, fn)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return spec.raw, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func (s stopSpec) matches(pkg *packages.Package, fn *ast.FuncDecl) (bool, error) {
+	switch {
+	case s.re != nil:
+		obj := pkg.TypesInfo.Defs[fn.Name]
+
+		return obj != nil && s.re.MatchString(funcKey(obj)), nil
+	case s.dir != "":
+		file := pkg.Fset.File(fn.Pos())
+		if file == nil {
+			return false, nil
+		}
+		dir, err := fsutils.TruePath(filepath.Dir(file.Name()))
+		if err != nil {
+			return false, fmt.Errorf("ascertaining true path: %w", err)
+		}
+		if !s.recursive {
+			return dir == s.dir, nil
+		}
+		rel, err := filepath.Rel(s.dir, dir)
+
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+	case s.importPath != "":
+		return pkg.PkgPath == s.importPath || strings.HasPrefix(pkg.PkgPath, s.importPath+"/"), nil
+	case s.target != nil:
+		inScope, err := specCoversFile(pkg, fn, s.target)
+		if err != nil {
+			return false, err
+		}
+
+		return inScope && matchesTargetSpec(pkg, fn, s.target), nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,52 @@
+package goctx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStopSpec_Kinds(t *testing.T) {
+	spec, err := parseStopSpec(`re:^example\.com/api\.`)
+	require.NoError(t, err)
+	assert.NotNil(t, spec.re)
+
+	dir := t.TempDir()
+	spec, err = parseStopSpec(filepath.ToSlash(dir) + "/...")
+	require.NoError(t, err)
+	wantDir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	assert.Equal(t, wantDir, spec.dir)
+	assert.True(t, spec.recursive)
+
+	_, err = parseStopSpec("./no/such/dir/...")
+	require.Error(t, err)
+
+	spec, err = parseStopSpec("example.com/app/api/...")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/app/api", spec.importPath)
+
+	spec, err = parseStopSpec("internal/api/server.go:(*Server).Get")
+	require.NoError(t, err)
+	require.NotNil(t, spec.target)
+	assert.Equal(t, "Server", spec.target.Recv)
+
+	spec, err = parseStopSpec("/abs/path/server.go:Get")
+	require.NoError(t, err)
+	require.NotNil(t, spec.target, "absolute file targets are not directory patterns")
+
+	_, err = parseStopSpec("re:(")
+	require.Error(t, err)
+}
+
+func TestParseStopSpecs_CombinesStopAtAndStopAts(t *testing.T) {
+	set, err := parseStopSpecs(Options{StopAt: "a.go:F", StopAts: []string{" ", "example.com/x/..."}})
+	require.NoError(t, err)
+	require.Len(t, set.specs, 2)
+
+	set, err = parseStopSpecs(Options{})
+	require.NoError(t, err)
+	require.Empty(t, set.specs)
+}
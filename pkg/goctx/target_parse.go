@@ -10,32 +10,105 @@ import (
 	"strings"
 )
 
+// targetSpec identifies a function or method declaration. It is either file-based
+// (File is set) or package-qualified (Qualified is set).
 type targetSpec struct {
 	File       string
+	Qualified  string // import-path-qualified member, e.g. example.com/pkg.(*T).Method
+	Recv       string // receiver base type name, when the spec names a method as Type.Method or (*Type).Method
 	FuncName   string
 	LineNumber int // Interpreted as 1-based line number when provided; 0 means unspecified
+	CursorLine int // 1-based line of a position inside the target (file.go:#L42, file.go:42:7); 0 means unspecified
+	CursorCol  int // 1-based column of the cursor position; 0 means any column on CursorLine
 }
 
-var targetRe = regexp.MustCompile(`^([^:]+):(\w+)(?::(\d+))?$`)
+const targetFormats = "path/to/file.go:Func[:N], path/to/file.go:Type.Method[:N], path/to/file.go:(*Type).Method[:N], " +
+	"path/to/file.go:#L42, path/to/file.go:42:7, example.com/pkg.Func or example.com/pkg.(*Type).Method"
+
+var (
+	fileSpecRe   = regexp.MustCompile(`^([^:]+):(.+)$`)
+	memberRe     = regexp.MustCompile(`^(?:\((\*)?(\w+)\)\.|(\w+)\.)?(\w+)(?::(\d+))?$`)
+	cursorLineRe = regexp.MustCompile(`^#L(\d+)$`)
+	cursorPosRe  = regexp.MustCompile(`^(\d+):(\d+)$`)
+	qualifiedRe  = regexp.MustCompile(`^[\w.\-~/]+\.(?:\(\*\w+\)\.)?\w+$`)
+)
 
 func parseTargetSpec(specStr string) (targetSpec, error) {
 	slog.Debug("parseTargetSpec start", slog.String("spec", strings.TrimSpace(specStr)))
 	specStr = filepath.ToSlash(strings.TrimSpace(specStr))
-	matches := targetRe.FindStringSubmatch(specStr)
+
+	matches := fileSpecRe.FindStringSubmatch(specStr)
 	if matches == nil {
-		return targetSpec{}, errors.New("invalid target format, want path/to/file.go:Func[:N]")
-	}
-	ord := 0
-	if matches[3] != "" {
-		v, err := strconv.Atoi(matches[3])
-		if err != nil || v <= 0 {
-			return targetSpec{}, fmt.Errorf("invalid line number N in %q", specStr)
+		// No file part: the only remaining form is a package-qualified member.
+		if !qualifiedRe.MatchString(specStr) || !strings.Contains(specStr, ".") {
+			return targetSpec{}, fmt.Errorf("invalid target format %q, want one of %s", specStr, targetFormats)
 		}
-		ord = v
+		slog.Debug("parseTargetSpec done", slog.String("qualified", specStr))
+
+		return targetSpec{Qualified: specStr}, nil
 	}
 
-	spec := targetSpec{File: matches[1], FuncName: matches[2], LineNumber: ord}
-	slog.Debug("parseTargetSpec done", slog.String("file", spec.File), slog.String("func", spec.FuncName), slog.Int("line", spec.LineNumber))
+	spec := targetSpec{File: matches[1]}
+	rest := matches[2]
+	switch {
+	case cursorLineRe.MatchString(rest):
+		line, err := positiveInt(cursorLineRe.FindStringSubmatch(rest)[1])
+		if err != nil {
+			return targetSpec{}, fmt.Errorf("invalid cursor line in %q", specStr)
+		}
+		spec.CursorLine = line
+	case cursorPosRe.MatchString(rest):
+		sub := cursorPosRe.FindStringSubmatch(rest)
+		line, lineErr := positiveInt(sub[1])
+		col, colErr := positiveInt(sub[2])
+		if lineErr != nil || colErr != nil {
+			return targetSpec{}, fmt.Errorf("invalid cursor position in %q", specStr)
+		}
+		spec.CursorLine, spec.CursorCol = line, col
+	default:
+		recv, name, line, err := parseMember(rest)
+		if err != nil {
+			return targetSpec{}, fmt.Errorf("%w in %q, want one of %s", err, specStr, targetFormats)
+		}
+		spec.Recv, spec.FuncName, spec.LineNumber = recv, name, line
+	}
+	slog.Debug("parseTargetSpec done",
+		slog.String("file", spec.File),
+		slog.String("recv", spec.Recv),
+		slog.String("func", spec.FuncName),
+		slog.Int("line", spec.LineNumber),
+		slog.Int("cursorLine", spec.CursorLine),
+		slog.Int("cursorCol", spec.CursorCol),
+	)
 
 	return spec, nil
 }
+
+// parseMember parses Func, Type.Method or (*Type).Method, each optionally followed by :N.
+// The receiver is returned without the pointer: a type cannot declare the same method on both
+// T and *T, so the base type name is enough to identify it.
+func parseMember(member string) (string, string, int, error) {
+	sub := memberRe.FindStringSubmatch(member)
+	if sub == nil {
+		return "", "", 0, errors.New("invalid target format")
+	}
+	line := 0
+	if sub[5] != "" {
+		v, err := positiveInt(sub[5])
+		if err != nil {
+			return "", "", 0, errors.New("invalid line number N")
+		}
+		line = v
+	}
+
+	return firstNonEmpty(sub[2], sub[3]), sub[4], line, nil
+}
+
+func positiveInt(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("not a positive number: %q", s)
+	}
+
+	return v, nil
+}
@@ -20,6 +20,24 @@ func TestParseTargetSpec_OK(t *testing.T) {
 	assert.Equal(t, 2, sp.LineNumber)
 }
 
+func TestParseTargetSpec_Forms(t *testing.T) {
+	cases := map[string]targetSpec{
+		"pkg/foo.go:(*Type).Close":           {File: "pkg/foo.go", Recv: "Type", FuncName: "Close"},
+		"pkg/foo.go:Type.Close":              {File: "pkg/foo.go", Recv: "Type", FuncName: "Close"},
+		"pkg/foo.go:Type.Close:7":            {File: "pkg/foo.go", Recv: "Type", FuncName: "Close", LineNumber: 7},
+		"pkg/foo.go:#L42":                    {File: "pkg/foo.go", CursorLine: 42},
+		"pkg/foo.go:42:7":                    {File: "pkg/foo.go", CursorLine: 42, CursorCol: 7},
+		"example.com/pkg.Func":               {Qualified: "example.com/pkg.Func"},
+		"example.com/pkg.(*T).Method":        {Qualified: "example.com/pkg.(*T).Method"},
+		"  gopkg.in/yaml.v3.(*Node).Decode ": {Qualified: "gopkg.in/yaml.v3.(*Node).Decode"},
+	}
+	for in, want := range cases {
+		got, err := parseTargetSpec(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+}
+
 func TestParseTargetSpec_Errors(t *testing.T) {
 	var err error
 
@@ -34,4 +52,10 @@ func TestParseTargetSpec_Errors(t *testing.T) {
 
 	_, err = parseTargetSpec("notvalid")
 	require.Error(t, err)
+
+	_, err = parseTargetSpec("pkg/foo.go:#L0")
+	require.Error(t, err)
+
+	_, err = parseTargetSpec("pkg/foo.go:(*Type.Close")
+	require.ErrorContains(t, err, "want one of")
 }
@@ -7,6 +7,8 @@ import (
 	"go/types"
 	"log/slog"
 	"slices"
+	"sort"
+	"strings"
 
 	"github.com/yaklabco/stave/pkg/fsutils"
 	"golang.org/x/tools/go/packages"
@@ -35,107 +37,227 @@ type targetResolution struct {
 // resolveTarget locates the target function declaration and its types.Object.
 // It now returns a targetResolution pointer and an error.
 func resolveTarget(pkgs []*packages.Package, spec targetSpec) (*targetResolution, error) {
-	slog.Debug("resolveTarget start", slog.String("file", spec.File), slog.String("func", spec.FuncName), slog.Int("line", spec.LineNumber))
-	absFile, err := fsutils.TruePath(spec.File)
-	if err != nil {
-		return nil, fmt.Errorf("ascertaining true path: %w", err)
-	}
+	slog.Debug("resolveTarget start",
+		slog.String("file", spec.File),
+		slog.String("qualified", spec.Qualified),
+		slog.String("recv", spec.Recv),
+		slog.String("func", spec.FuncName),
+		slog.Int("line", spec.LineNumber),
+	)
+	var (
+		scopeFound bool
+		matches    []*targetResolution
+		named      []*targetResolution // declarations sharing the requested name, for error messages
+		seen       = make(map[*ast.FuncDecl]bool)
+	)
 	for _, pkg := range pkgs {
 		for _, fileAST := range pkg.Syntax {
-			posFile := pkg.Fset.File(fileAST.Pos())
-			if posFile == nil {
-				continue
-			}
-			fp, err := fsutils.TruePath(posFile.Name())
+			inScope, err := specCoversFile(pkg, fileAST, &spec)
 			if err != nil {
-				return nil, fmt.Errorf("ascertaining true path: %w", err)
+				return nil, err
 			}
-
-			if fp != absFile {
+			if !inScope {
 				continue
 			}
-			candidates := findFuncDeclsByName(fileAST, spec.FuncName)
-			if len(candidates) == 0 {
-				return nil, fmt.Errorf("no function or method named %s in %s", spec.FuncName, spec.File)
-			}
-			// If a line number was provided, pick the function starting at that line
-			if spec.LineNumber > 0 {
-				var decl *ast.FuncDecl
-				for _, c := range candidates {
-					start := pkg.Fset.Position(c.Pos()).Line
-					if start == spec.LineNumber {
-						decl = c
-						break
-					}
+			scopeFound = true
+			for _, d := range fileAST.Decls {
+				decl, ok := d.(*ast.FuncDecl)
+				// Test variants of a package share the same ASTs; consider each declaration once.
+				if !ok || seen[decl] {
+					continue
 				}
-				if decl == nil {
-					return nil, fmt.Errorf("no %s starting at line %d in %s", spec.FuncName, spec.LineNumber, spec.File)
+				seen[decl] = true
+				tr := &targetResolution{Pkg: pkg, FileAST: fileAST, Fset: pkg.Fset, Info: pkg.TypesInfo, Decl: decl}
+				if matchesTargetSpec(pkg, decl, &spec) {
+					matches = append(matches, tr)
+				} else if decl.Name.Name == specFuncName(pkg, &spec) {
+					named = append(named, tr)
 				}
-				obj := pkg.TypesInfo.Defs[decl.Name]
-				if obj == nil {
-					return nil, fmt.Errorf("resolving function object for %s", spec.FuncName)
-				}
-				tr := &targetResolution{Pkg: pkg, FileAST: fileAST, Fset: pkg.Fset, Info: pkg.TypesInfo, Decl: decl, Obj: obj}
-				slog.Debug("resolveTarget found by line", slog.String("file", pkg.Fset.File(decl.Pos()).Name()), slog.String("func", decl.Name.Name), slog.Int("line", spec.LineNumber))
-
-				return tr, nil
 			}
-			// No line provided
-			if len(candidates) > 1 {
-				return nil, fmt.Errorf(
-					"ambiguous function %s in %s: found %d; please disambiguate using a line number as %s:%s:N (N is 1-based line)",
-					spec.FuncName, spec.File, len(candidates), spec.File, spec.FuncName,
-				)
-			}
-			decl := candidates[0]
-			obj := pkg.TypesInfo.Defs[decl.Name]
-			if obj == nil {
-				return nil, fmt.Errorf("resolving function object for %s", spec.FuncName)
-			}
-			tr := &targetResolution{Pkg: pkg, FileAST: fileAST, Fset: pkg.Fset, Info: pkg.TypesInfo, Decl: decl, Obj: obj}
-			slog.Debug("resolveTarget found", slog.String("file", pkg.Fset.File(decl.Pos()).Name()), slog.String("func", decl.Name.Name))
-
-			return tr, nil
 		}
 	}
 
-	return nil, fmt.Errorf("could not find file %s in loaded packages", spec.File)
+	switch {
+	case !scopeFound && spec.Qualified != "":
+		return nil, fmt.Errorf("could not find a loaded package declaring %s", spec.Qualified)
+	case !scopeFound:
+		return nil, fmt.Errorf("could not find file %s in loaded packages", spec.File)
+	case len(matches) == 0:
+		return nil, noTargetMatchError(&spec, named)
+	case len(matches) > 1:
+		return nil, fmt.Errorf("ambiguous target %s: found %d matches; please disambiguate using one of:%s",
+			describeSpec(&spec), len(matches), listCandidates(matches))
+	}
+
+	tr := matches[0]
+	tr.Obj = tr.Info.Defs[tr.Decl.Name]
+	if tr.Obj == nil {
+		return nil, fmt.Errorf("resolving function object for %s", tr.Decl.Name.Name)
+	}
+	slog.Debug("resolveTarget found",
+		slog.String("file", tr.Fset.File(tr.Decl.Pos()).Name()),
+		slog.String("func", tr.Decl.Name.Name),
+		slog.Int("line", tr.Fset.Position(tr.Decl.Pos()).Line),
+	)
+
+	return tr, nil
 }
 
-func findFuncDeclsByName(file *ast.File, name string) []*ast.FuncDecl {
-	var out []*ast.FuncDecl
-	for _, d := range file.Decls {
-		fd, ok := d.(*ast.FuncDecl)
+// specCoversFile reports whether the node's file may contain the declaration named by spec: the
+// spec's file for file-based specs, any file of the named package for package-qualified ones.
+func specCoversFile(pkg *packages.Package, node ast.Node, spec *targetSpec) (bool, error) {
+	if spec.Qualified != "" {
+		_, ok := strings.CutPrefix(spec.Qualified, pkg.PkgPath+".")
+
+		return ok, nil
+	}
+	posFile := pkg.Fset.File(node.Pos())
+	if posFile == nil {
+		return false, nil
+	}
+
+	return sameFileName(posFile.Name(), spec.File)
+}
+
+// matchesTargetSpec reports whether fn, declared in pkg, is the declaration named by spec.
+// The caller is responsible for checking that fn lives in a file covered by the spec.
+func matchesTargetSpec(pkg *packages.Package, fn *ast.FuncDecl, spec *targetSpec) bool {
+	if spec.CursorLine > 0 {
+		start := pkg.Fset.Position(fn.Pos())
+		end := pkg.Fset.Position(fn.End())
+		col := max(spec.CursorCol, 1)
+		afterStart := spec.CursorLine > start.Line || (spec.CursorLine == start.Line && (spec.CursorCol == 0 || col >= start.Column))
+		beforeEnd := spec.CursorLine < end.Line || (spec.CursorLine == end.Line && col <= end.Column)
+
+		return afterStart && beforeEnd
+	}
+
+	recv, name, line := spec.Recv, spec.FuncName, spec.LineNumber
+	// A bare file-based name also matches methods (disambiguated with :N); package-qualified
+	// names follow Go syntax, where pkg.Func names a function only.
+	strictRecv := spec.Qualified != ""
+	if strictRecv {
+		member, ok := strings.CutPrefix(spec.Qualified, pkg.PkgPath+".")
 		if !ok {
-			continue
+			return false
 		}
-		if fd.Name.Name == name {
-			out = append(out, fd)
+		var err error
+		if recv, name, line, err = parseMember(member); err != nil {
+			return false
 		}
 	}
+	if fn.Name.Name != name {
+		return false
+	}
+	if (recv != "" || strictRecv) && recvBaseName(fn) != recv {
+		return false
+	}
 
-	return out
+	return line < 1 || pkg.Fset.Position(fn.Pos()).Line == line
 }
 
-func sameFile(p *packages.Package, fn *ast.FuncDecl, spec *targetSpec) (bool, error) {
-	if spec == nil {
-		return false, nil
+// specFuncName returns the function or method name the spec asks for, if any.
+func specFuncName(pkg *packages.Package, spec *targetSpec) string {
+	if spec.Qualified == "" {
+		return spec.FuncName
 	}
-	fi := p.Fset.File(fn.Pos())
-	if fi == nil {
-		return false, nil
+	member, _ := strings.CutPrefix(spec.Qualified, pkg.PkgPath+".")
+	_, name, _, err := parseMember(member)
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// recvBaseName returns the receiver's base type name of a method ("T" for *T and T[K]), or ""
+// for a plain function.
+func recvBaseName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	expr := fn.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
 	}
-	abs1, err := fsutils.TruePath(fi.Name())
+}
+
+// declDisplayName renders a declaration the way targets name it: Func, T.Method or (*T).Method.
+func declDisplayName(fn *ast.FuncDecl) string {
+	recv := recvBaseName(fn)
+	if recv == "" {
+		return fn.Name.Name
+	}
+	if _, ok := fn.Recv.List[0].Type.(*ast.StarExpr); ok {
+		return "(*" + recv + ")." + fn.Name.Name
+	}
+
+	return recv + "." + fn.Name.Name
+}
+
+func describeSpec(spec *targetSpec) string {
+	switch {
+	case spec.Qualified != "":
+		return spec.Qualified
+	case spec.CursorLine > 0 && spec.CursorCol > 0:
+		return fmt.Sprintf("%s:%d:%d", spec.File, spec.CursorLine, spec.CursorCol)
+	case spec.CursorLine > 0:
+		return fmt.Sprintf("%s:#L%d", spec.File, spec.CursorLine)
+	case spec.Recv != "":
+		return spec.File + ":" + spec.Recv + "." + spec.FuncName
+	}
+
+	return spec.File + ":" + spec.FuncName
+}
+
+// listCandidates renders one line per candidate with the file-based target naming it exactly.
+func listCandidates(candidates []*targetResolution) string {
+	lines := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		pos := c.Fset.Position(c.Decl.Pos())
+		lines = append(lines, fmt.Sprintf("\n  %s:%s:%d", pos.Filename, declDisplayName(c.Decl), pos.Line))
+	}
+	sort.Strings(lines)
+
+	return strings.Join(lines, "")
+}
+
+func noTargetMatchError(spec *targetSpec, named []*targetResolution) error {
+	if spec.CursorLine > 0 {
+		return fmt.Errorf("no function or method declaration encloses %s", describeSpec(spec))
+	}
+	if len(named) == 0 {
+		return fmt.Errorf("no function or method matching %s", describeSpec(spec))
+	}
+
+	return fmt.Errorf("no function or method matching %s; candidates with the same name:%s", describeSpec(spec), listCandidates(named))
+}
+
+// sameFileName reports whether two paths name the same file, resolving symlinks.
+func sameFileName(a, b string) (bool, error) {
+	absA, err := fsutils.TruePath(a)
 	if err != nil {
 		return false, fmt.Errorf("ascertaining true path: %w", err)
 	}
-
-	abs2, err := fsutils.TruePath(spec.File)
+	absB, err := fsutils.TruePath(b)
 	if err != nil {
 		return false, fmt.Errorf("ascertaining true path: %w", err)
 	}
 
-	return abs1 == abs2, nil
+	return absA == absB, nil
 }
 
 func enclosingFuncDecl(file *ast.File, targetNode ast.Node) *ast.FuncDecl {
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"example.com/e2e/cli"
+	"example.com/e2e/web"
+)
+
+func load(ctx context.Context) string {
+	return "row"
+}
+
+// Show renders a row.
+func Show(c *web.Context) string {
+	ctx := c.Request.Context()
+	return load(ctx)
+}
+
+// Run runs the command.
+func Run(cmd *cli.Command, args []string) error {
+	ctx := cmd.Context()
+	_ = load(ctx)
+	return nil
+}
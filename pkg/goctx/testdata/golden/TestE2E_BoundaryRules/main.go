@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/app"
+	"example.com/e2e/cli"
+	"example.com/e2e/web"
+)
+
+func main() {
+	fmt.Println(app.Show(&web.Context{}), app.Run(&cli.Command{}, nil))
+}
@@ -0,0 +1,27 @@
+package cmds
+
+import (
+	"fmt"
+
+	"example.com/e2e/app"
+	"github.com/spf13/cobra"
+)
+
+// NewRoot returns the root command.
+func NewRoot() *cobra.Command {
+	return &cobra.Command{
+		Use: "app",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			_ = app.Load(ctx)
+			return nil
+		},
+		RunE: runRoot,
+	}
+}
+
+func runRoot(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	fmt.Println(app.Load(ctx))
+	return nil
+}
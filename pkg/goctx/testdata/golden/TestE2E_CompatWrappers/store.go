@@ -0,0 +1,44 @@
+package store
+
+import "context"
+
+func lookup(ctx context.Context, id int) string {
+	return "row"
+}
+
+// GetContext returns the row for id.
+func GetContext(ctx context.Context, id int) (string, error) {
+	return lookup(ctx, id), nil
+}
+
+// Get is like GetContext but uses context.Background().
+//
+// Deprecated: Use GetContext instead.
+func Get(id int) (string, error) {
+	return GetContext(context.Background(), id)
+}
+
+// Store reads rows.
+type Store struct{}
+
+// NamesContext returns prefix followed by the rows of ids.
+func (s *Store) NamesContext(ctx context.Context, prefix string, ids ...int) []string {
+	out := []string{prefix}
+	for _, id := range ids {
+		out = append(out, lookup(ctx, id))
+	}
+	return out
+}
+
+// Names is like NamesContext but uses context.Background().
+//
+// Deprecated: Use NamesContext instead.
+func (s *Store) Names(prefix string, ids ...int) []string {
+	return s.NamesContext(context.Background(), prefix, ids...)
+}
+
+// Handle serves a request that already carries a context.
+func Handle(ctx context.Context, id int) string {
+	v, _ := GetContext(ctx, id)
+	return v
+}
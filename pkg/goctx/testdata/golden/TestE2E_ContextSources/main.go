@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+
+	"example.com/e2e/svc"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	fmt.Println(svc.Plain(ctx, "42"))
+}
@@ -0,0 +1,15 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// Load reads the value stored under id.
+func Load(ctx context.Context, id string) (string, error) {
+	if id == "" {
+		return "", errors.New("empty id")
+	}
+
+	return "value of " + id, nil
+}
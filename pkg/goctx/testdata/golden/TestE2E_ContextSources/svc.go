@@ -0,0 +1,41 @@
+package svc
+
+import (
+	"context"
+	"net/http"
+
+	"example.com/e2e/jobs"
+	"example.com/e2e/store"
+)
+
+// Describe is not a handler, but the request it is given carries a context.
+func Describe(r *http.Request, id string) string {
+	v, _ := store.Load(r.Context(), id)
+
+	return r.Method + " " + v
+}
+
+// Worker keeps the context it was started with.
+type Worker struct {
+	ctx  context.Context
+	name string
+}
+
+// Run loads the value of the worker.
+func (w *Worker) Run() error {
+	_, err := store.Load(w.ctx, w.name)
+
+	return err
+}
+
+// Process handles a job.
+func Process(j *jobs.Job) {
+	_, _ = store.Load(j.Ctx(), j.ID)
+}
+
+// Plain has nothing to derive a context from.
+func Plain(ctx context.Context, id string) string {
+	v, _ := store.Load(ctx, id)
+
+	return v
+}
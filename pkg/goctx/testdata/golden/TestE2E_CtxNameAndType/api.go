@@ -0,0 +1,22 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"example.com/e2e/appctx"
+	"example.com/e2e/svc"
+	"example.com/e2e/web"
+)
+
+// Serve handles a request.
+func Serve(r *web.Request) string {
+	c := appctx.New(r.Context())
+	return svc.Fetch(c, 1) + svc.Audit(appctx.New(r.Context()), 2)
+}
+
+// Handle serves a row over net/http.
+func Handle(w http.ResponseWriter, r *http.Request) {
+	c := appctx.New(r.Context())
+	_, _ = io.WriteString(w, svc.Fetch(c, 3))
+}
@@ -0,0 +1,8 @@
+package store
+
+import "example.com/e2e/appctx"
+
+// Load loads a row.
+func Load(c appctx.Ctx, id int) string {
+	return "row"
+}
@@ -0,0 +1,24 @@
+package svc
+
+import (
+	"context"
+
+	"example.com/e2e/appctx"
+	"example.com/e2e/store"
+)
+
+// Fetch loads a row.
+func Fetch(c appctx.Ctx, id int) string {
+	return store.Load(c, id)
+}
+
+// Audit loads a row and logs with the request logger.
+func Audit(c appctx.Ctx, id int) string {
+	c.Logger().Info("audit", "id", id)
+	return store.Load(c, id)
+}
+
+// Refresh reloads a row in the background.
+func Refresh(c appctx.Ctx, ctx context.Context, id int) string {
+	return Fetch(c, id)
+}
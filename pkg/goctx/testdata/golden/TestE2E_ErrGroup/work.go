@@ -0,0 +1,71 @@
+package work
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func handle(ctx context.Context, item string) error {
+	return nil
+}
+
+// Process handles the items concurrently.
+func Process(ctx context.Context, items []string) error {
+	gctx := "taken"
+	_ = gctx
+	// fan out, at most four at a time
+	g, gctx2 := errgroup.WithContext(ctx) // workers
+	g.SetLimit(4)
+	for _, item := range items {
+		g.Go(func() error {
+			return handle(gctx2, item)
+		})
+	}
+	return g.Wait()
+}
+
+// Notify already has a ctx; only the group of handle calls is converted.
+func Notify(ctx context.Context, items []string) error {
+	var audit errgroup.Group
+	audit.Go(func() error {
+		return ctx.Err()
+	})
+	eg, gctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		if err := gctx.Err(); err != nil {
+			return err
+		}
+		return handle(gctx, "notify")
+	})
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return audit.Wait()
+}
+
+// Shared hands its group out, so it stays a zero value.
+func Shared(ctx context.Context, items []string) error {
+	var g errgroup.Group
+	g.Go(func() error {
+		return handle(ctx, items[0])
+	})
+	wait(&g)
+	return nil
+}
+
+func wait(g *errgroup.Group) {
+	_ = g.Wait()
+}
+
+// Late declares its ctx after the group, which therefore stays a zero value.
+func Late() error {
+	var g errgroup.Group
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.Go(func() error {
+		return handle(ctx, "late")
+	})
+	_ = ctx
+	return g.Wait()
+}
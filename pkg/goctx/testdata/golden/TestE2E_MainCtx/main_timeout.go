@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func work(ctx context.Context) string {
+	return "done"
+}
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	fmt.Println("starting")
+	fmt.Println(work(ctx))
+}
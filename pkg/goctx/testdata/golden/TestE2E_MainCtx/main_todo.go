@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func work(ctx context.Context) string {
+	return "done"
+}
+
+func main() {
+	ctx := context.TODO()
+	fmt.Println("starting")
+	fmt.Println(work(ctx))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	signal2 "os/signal"
+	"syscall"
+	tm "time"
+)
+
+var start = tm.Now()
+
+func work(ctx context.Context) string {
+	return "done"
+}
+
+// signal reports an event.
+func signal(event string) {
+	fmt.Println(event, "after", tm.Since(start))
+}
+
+func main() {
+	ctx, stop := signal2.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cancel := func() { signal("cancelled") }
+	defer cancel()
+	fmt.Println(work(ctx))
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	tm "time"
+)
+
+var start = tm.Now()
+
+func work(ctx context.Context) string {
+	return "done"
+}
+
+// signal reports an event.
+func signal(event string) {
+	fmt.Println(event, "after", tm.Since(start))
+}
+
+func main() {
+	ctx, cancel2 := context.WithTimeout(context.Background(), 90*tm.Second)
+	defer cancel2()
+	cancel := func() { signal("cancelled") }
+	defer cancel()
+	fmt.Println(work(ctx))
+}
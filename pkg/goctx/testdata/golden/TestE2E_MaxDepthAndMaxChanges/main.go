@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func leaf(ctx context.Context) string {
+	return "leaf"
+}
+
+func a(ctx context.Context) string {
+	return leaf(ctx)
+}
+
+func b(ctx context.Context) string {
+	return a(ctx)
+}
+
+func c() string {
+	return b(context.TODO())
+}
+
+func main() {
+	fmt.Println(c())
+}
@@ -0,0 +1,23 @@
+package db
+
+import "context"
+
+// GetUser loads one user.
+func GetUser(ctx context.Context, id int) string {
+	return lookup(id)
+}
+
+// ListUsers loads all users, one by one.
+func ListUsers(ctx context.Context) []string {
+	return []string{GetUser(ctx, 1), GetUser(ctx, 2)}
+}
+
+// CountUsers is not a target and must stay untouched.
+func CountUsers() int {
+	return 2
+}
+
+func lookup(id int) string {
+	_ = id
+	return "user"
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+
+	"example.com/e2e/db"
+	"fmt"
+)
+
+func show(ctx context.Context) {
+	fmt.Println(db.GetUser(ctx, 1))
+	fmt.Println(db.ListUsers(ctx))
+	fmt.Println(db.CountUsers())
+}
+
+func main() {
+	ctx := context.Background()
+	show(ctx)
+}
@@ -0,0 +1,17 @@
+package svc
+
+import (
+	"database/sql"
+
+	"example.com/e2e/repo"
+)
+
+// Register creates a user.
+func Register(tx *sql.Tx, name string) error {
+	return repo.Insert(tx, name)
+}
+
+// RegisterIn creates a user in an open transaction.
+func RegisterIn(tx *sql.Tx, name string) error {
+	return repo.Insert(tx, name)
+}
@@ -0,0 +1,13 @@
+package db
+
+import "context"
+
+// Query is the leaf in need of a context.
+func Query(ctx context.Context) string {
+	return "row"
+}
+
+// Cached is exported but internal: its signature may change.
+func Cached(ctx context.Context) string {
+	return Query(ctx)
+}
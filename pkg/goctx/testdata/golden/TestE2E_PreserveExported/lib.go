@@ -0,0 +1,23 @@
+package lib
+
+import (
+	"context"
+	"example.com/e2e/internal/db"
+)
+
+// Get is part of the public API.
+func Get() string {
+	return db.Query(context.TODO())
+}
+
+// Client is part of the public API.
+type Client struct{}
+
+// Fetch is part of the public API too.
+func (c *Client) Fetch() string {
+	return helper(context.TODO())
+}
+
+func helper(ctx context.Context) string {
+	return db.Query(ctx)
+}
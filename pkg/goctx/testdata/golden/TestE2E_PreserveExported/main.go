@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+
+	"example.com/e2e/internal/db"
+	"example.com/e2e/lib"
+	"fmt"
+)
+
+func main() {
+	ctx := context.Background()
+	c := &lib.Client{}
+	fmt.Println(lib.Get(), c.Fetch(), db.Cached(ctx))
+}
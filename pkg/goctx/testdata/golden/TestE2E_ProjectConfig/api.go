@@ -0,0 +1,8 @@
+package api
+
+import "example.com/e2e/store"
+
+// Handle serves a request.
+func Handle() string {
+	return store.Get(ctx)
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+
+	"example.com/e2e/api"
+	"example.com/e2e/gen"
+	"example.com/e2e/store"
+	"fmt"
+)
+
+func main() {
+	ctx := context.TODO()
+	fmt.Println(api.Handle(), gen.Gen(ctx), store.Get(ctx))
+}
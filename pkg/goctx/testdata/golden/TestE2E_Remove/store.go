@@ -0,0 +1,17 @@
+package store
+
+import "context"
+
+// Get reads id.
+func Get(id string) string {
+	return lookup(id)
+}
+
+func lookup(id string) string {
+	return id
+}
+
+// Put uses its ctx, so it keeps it.
+func Put(ctx context.Context, id string) error {
+	return ctx.Err()
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+
+	"example.com/e2e/svc"
+	"log/slog"
+)
+
+func main() {
+	ctx := context.Background()
+	slog.InfoContext(ctx, "starting")
+	svc.Report(ctx, nil)
+}
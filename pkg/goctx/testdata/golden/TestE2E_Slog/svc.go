@@ -0,0 +1,46 @@
+package svc
+
+import (
+	"context"
+	"log/slog"
+)
+
+func init() {
+	slog.Debug("svc loaded")
+}
+
+type Service struct {
+	log *slog.Logger
+}
+
+// Sync stores every id.
+func (s *Service) Sync(ctx context.Context, ids []string) error {
+	s.log.InfoContext(ctx, "sync", "ids", len(ids))
+	for _, id := range ids {
+		s.store(ctx, id)
+	}
+	return nil
+}
+
+func (s *Service) store(ctx context.Context, id string) {
+	s.log.With("id", id).DebugContext(ctx, "storing")
+	if id == "" {
+		slog.WarnContext(ctx, "empty id")
+	}
+}
+
+func describe(ctx context.Context, args ...any) {
+	slog.InfoContext(ctx, "describe", args...)
+}
+
+// Report describes ids.
+func Report(ctx context.Context, ids []string) {
+	describe(ctx, "count", len(ids))
+}
+
+// Start logs before and after it sets up its context.
+func Start(ctx context.Context) {
+	slog.InfoContext(ctx, "starting")
+	ctx = context.Background()
+	slog.InfoContext(ctx, "started")
+}
@@ -0,0 +1,8 @@
+package nightly
+
+import "example.com/e2e/svc"
+
+// Run is inside ./internal/jobs/...: a boundary by package pattern.
+func Run() string {
+	return svc.Fetch(ctx)
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"context"
+	"example.com/e2e/svc"
+)
+
+type Server struct{}
+
+// Get is part of the public API: a boundary by regular expression.
+func (s *Server) Get() string {
+	return s.fetch(ctx)
+}
+
+// fetch is unexported and keeps propagating.
+func (s *Server) fetch(ctx context.Context) string {
+	return svc.Fetch(ctx)
+}
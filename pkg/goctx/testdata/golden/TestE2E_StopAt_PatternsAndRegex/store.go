@@ -0,0 +1,8 @@
+package store
+
+import "context"
+
+// Load is the leaf in need of a context.
+func Load(ctx context.Context) string {
+	return "value"
+}
@@ -0,0 +1,11 @@
+package svc
+
+import (
+	"context"
+	"example.com/e2e/store"
+)
+
+// Fetch is called from the API and from background jobs.
+func Fetch(ctx context.Context) string {
+	return store.Load(ctx)
+}
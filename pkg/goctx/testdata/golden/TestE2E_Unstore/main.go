@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"example.com/e2e/worker"
+)
+
+func main() {
+	ctx := context.Background()
+	w := worker.New("poller")
+	if err := w.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Worker polls a queue until its context is done.
+type Worker struct {
+	name     string
+	interval time.Duration
+}
+
+// New returns a worker bound to ctx.
+func New(name string) *Worker {
+	return &Worker{
+		name:     name,
+		interval: time.Second,
+	}
+}
+
+// Poll waits for one interval.
+func (w *Worker) Poll(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(w.interval):
+		return nil
+	}
+}
+
+// Run polls until an error occurs.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		if err := w.Poll(ctx); err != nil {
+			return fmt.Errorf("%s: %w", w.name, err)
+		}
+	}
+}
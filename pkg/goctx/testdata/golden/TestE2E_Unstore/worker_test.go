@@ -0,0 +1,14 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	ctx := t.Context()
+	w := Worker{"test", time.Millisecond}
+	if err := w.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
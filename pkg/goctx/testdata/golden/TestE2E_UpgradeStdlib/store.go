@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"example.com/e2e/legacy"
+)
+
+// Store reads and writes users.
+type Store struct {
+	db *sql.DB
+}
+
+// Count has a ctx but runs its query without it.
+func (s *Store) Count(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n)
+
+	return n, err
+}
+
+// Rename renames a user in a transaction.
+func (s *Store) Rename(ctx context.Context, id int, name string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET name = ? WHERE id = ?", name, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Names lists the user names.
+func Names(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// Fetch gets url.
+func Fetch(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.DefaultClient.Do(req)
+}
+
+// Probe gets url the short way.
+func Probe(ctx context.Context, url string) (*http.Response, error) {
+	return http.Get(url)
+}
+
+// Legacy has no ctx to pass.
+func Legacy(url string) (*http.Response, error) {
+	return http.Get(url)
+}
+
+// Ping dials addr.
+func Ping(ctx context.Context, addr string) error {
+	conn, err := (&net.Dialer{Timeout: time.Second}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// Build builds the module.
+func Build(ctx context.Context) error {
+	return exec.CommandContext(ctx, "go", "build", "./...").Run()
+}
+
+// Twice does 2.
+func Twice(ctx context.Context) int {
+	return legacy.DoCtx(ctx, 2)
+}
+
+// Seed inserts the first user before it sets up its context.
+func Seed(db *sql.DB) error {
+	if _, err := db.Exec("INSERT INTO users (name) VALUES ('root')"); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "INSERT INTO users (name) VALUES ('admin')"); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, "INSERT INTO users (name) VALUES ('guest')")
+
+	return err
+}
+
+// Rebuild vets the module in the background while it builds it.
+func Rebuild() error {
+	go func(ctx context.Context) {
+		_ = exec.CommandContext(ctx, "go", "vet", "./...").Run()
+	}(context.Background())
+
+	return exec.Command("go", "build", "./...").Run()
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"example.com/e2e/store"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+)
+
+// Server serves rows over net/http.
+type Server struct{}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	fmt.Fprint(w, store.Load(ctx))
+}
+
+// Register registers the handlers of every framework.
+func Register(mux *http.ServeMux, g *gin.Engine, e *echo.Echo, f *fiber.App) {
+	mux.HandleFunc("/rows", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		fmt.Fprint(w, store.Load(ctx))
+	})
+	g.GET("/rows", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		_ = store.Load(ctx)
+	})
+	e.GET("/rows", showRow)
+	f.Get("/rows", func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		_ = store.Load(ctx)
+		return nil
+	})
+}
+
+func showRow(c echo.Context) error {
+	ctx := c.Request().Context()
+	_ = store.Load(ctx)
+	return nil
+}
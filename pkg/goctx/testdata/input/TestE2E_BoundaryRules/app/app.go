@@ -0,0 +1,21 @@
+package app
+
+import (
+	"example.com/e2e/cli"
+	"example.com/e2e/web"
+)
+
+func load() string {
+	return "row"
+}
+
+// Show renders a row.
+func Show(c *web.Context) string {
+	return load()
+}
+
+// Run runs the command.
+func Run(cmd *cli.Command, args []string) error {
+	_ = load()
+	return nil
+}
@@ -0,0 +1,13 @@
+package cli
+
+import "context"
+
+// Command is a command in the style of cobra.
+type Command struct {
+	ctx context.Context
+}
+
+// Context returns the command's context.
+func (c *Command) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,8 @@
+package web
+
+import "net/http"
+
+// Context is a request context in the style of gin or echo.
+type Context struct {
+	Request *http.Request
+}
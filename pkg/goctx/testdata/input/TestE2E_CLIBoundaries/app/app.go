@@ -0,0 +1,6 @@
+package app
+
+// Load loads a row.
+func Load() string {
+	return "row"
+}
@@ -0,0 +1,25 @@
+package cmds
+
+import (
+	"fmt"
+
+	"example.com/e2e/app"
+	"github.com/spf13/cobra"
+)
+
+// NewRoot returns the root command.
+func NewRoot() *cobra.Command {
+	return &cobra.Command{
+		Use: "app",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			_ = app.Load()
+			return nil
+		},
+		RunE: runRoot,
+	}
+}
+
+func runRoot(cmd *cobra.Command, args []string) error {
+	fmt.Println(app.Load())
+	return nil
+}
@@ -0,0 +1,30 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+
+	"example.com/e2e/app"
+	v2 "github.com/urfave/cli/v2"
+	v3 "github.com/urfave/cli/v3"
+)
+
+// NewV2 returns a urfave/cli v2 app.
+func NewV2() *v2.App {
+	return &v2.App{
+		Action: func(c *v2.Context) error {
+			fmt.Println(app.Load())
+			return nil
+		},
+	}
+}
+
+// NewV3 returns a urfave/cli v3 command.
+func NewV3() *v3.Command {
+	return &v3.Command{
+		Action: func(_ context.Context, cmd *v3.Command) error {
+			fmt.Println(app.Load())
+			return nil
+		},
+	}
+}
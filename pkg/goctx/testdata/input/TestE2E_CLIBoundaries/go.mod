@@ -0,0 +1,15 @@
+module example.com/e2e
+
+go 1.21
+
+require (
+	github.com/spf13/cobra v1.0.0
+	github.com/urfave/cli/v2 v2.0.0
+	github.com/urfave/cli/v3 v3.0.0
+)
+
+replace (
+	github.com/spf13/cobra => ./stubs/cobra
+	github.com/urfave/cli/v2 => ./stubs/urfave-cli-v2
+	github.com/urfave/cli/v3 => ./stubs/urfave-cli-v3
+)
@@ -0,0 +1,7 @@
+package main
+
+import "example.com/e2e/cmds"
+
+func main() {
+	_, _, _ = cmds.NewRoot(), cmds.NewV2(), cmds.NewV3()
+}
@@ -0,0 +1,16 @@
+// Package cobra is a minimal stand-in for github.com/spf13/cobra.
+package cobra
+
+import "context"
+
+type Command struct {
+	Use     string
+	PreRunE func(cmd *Command, args []string) error
+	RunE    func(cmd *Command, args []string) error
+
+	ctx context.Context
+}
+
+func (c *Command) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,12 @@
+// Package cli is a minimal stand-in for github.com/urfave/cli/v2.
+package cli
+
+import "context"
+
+type Context struct {
+	Context context.Context
+}
+
+type App struct {
+	Action func(*Context) error
+}
@@ -0,0 +1,8 @@
+// Package cli is a minimal stand-in for github.com/urfave/cli/v3.
+package cli
+
+import "context"
+
+type Command struct {
+	Action func(context.Context, *Command) error
+}
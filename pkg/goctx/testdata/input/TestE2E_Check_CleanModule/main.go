@@ -0,0 +1,13 @@
+package main
+
+import "context"
+
+func load(ctx context.Context) string {
+	_ = ctx
+	return "x"
+}
+
+func main() {
+	ctx := context.Background()
+	_ = load(ctx)
+}
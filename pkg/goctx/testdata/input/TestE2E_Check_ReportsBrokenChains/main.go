@@ -0,0 +1,44 @@
+package main
+
+import "context"
+
+// fetch creates its own root context instead of accepting one.
+func fetch() string {
+	ctx := context.Background()
+	return load(ctx)
+}
+
+func load(ctx context.Context) string {
+	_ = ctx
+	return "x"
+}
+
+// handle has a ctx but calls fetch, which creates its own.
+func handle(ctx context.Context) {
+	_ = load(ctx)
+	_ = fetch()
+}
+
+// handleTODO passes a root context although ctx is in scope.
+func handleTODO(ctx context.Context) {
+	_ = load(context.TODO())
+	_ = ctx
+}
+
+// ignoresCtx has an unused ctx parameter but its callee needs one.
+func ignoresCtx(_ context.Context) {
+	_ = load(context.Background())
+}
+
+// fine passes its ctx along.
+func fine(ctx context.Context) {
+	_ = load(ctx)
+}
+
+func main() {
+	ctx := context.Background()
+	handle(ctx)
+	handleTODO(ctx)
+	ignoresCtx(ctx)
+	fine(ctx)
+}
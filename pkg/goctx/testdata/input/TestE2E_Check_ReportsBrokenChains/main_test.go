@@ -0,0 +1,8 @@
+package main
+
+import "testing"
+
+// The test boundary can derive t.Context(), so calling fetch is reported.
+func TestFetch(t *testing.T) {
+	_ = fetch()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+)
+
+type key struct{}
+
+func load(ctx context.Context) string {
+	_ = ctx
+	return "x"
+}
+
+// fetch declares its ctx from a root context: no ctx is in scope at that call yet.
+func fetch(id int) string {
+	ctx := context.WithValue(context.Background(), key{}, id)
+	return load(ctx)
+}
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	_ = load(ctx)
+	_ = load(context.TODO())
+}
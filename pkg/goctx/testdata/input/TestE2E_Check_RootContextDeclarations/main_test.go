@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	_ = ctx
+	code := m.Run()
+	cancel()
+	os.Exit(code)
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/store"
+)
+
+func main() {
+	s := &store.Store{}
+	v, _ := store.Get(1)
+	fmt.Println(v, s.Names("rows", 1, 2))
+}
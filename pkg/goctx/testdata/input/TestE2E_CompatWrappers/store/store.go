@@ -0,0 +1,30 @@
+package store
+
+import "context"
+
+func lookup(id int) string {
+	return "row"
+}
+
+// Get returns the row for id.
+func Get(id int) (string, error) {
+	return lookup(id), nil
+}
+
+// Store reads rows.
+type Store struct{}
+
+// Names returns prefix followed by the rows of ids.
+func (s *Store) Names(prefix string, ids ...int) []string {
+	out := []string{prefix}
+	for _, id := range ids {
+		out = append(out, lookup(id))
+	}
+	return out
+}
+
+// Handle serves a request that already carries a context.
+func Handle(ctx context.Context, id int) string {
+	v, _ := Get(id)
+	return v
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import "context"
+
+// Job is a unit of background work carrying the context it runs under.
+type Job struct {
+	ID  string
+	ctx context.Context
+}
+
+// New returns a job running under ctx.
+func New(ctx context.Context, id string) *Job {
+	return &Job{ID: id, ctx: ctx}
+}
+
+// Ctx returns the context of the job.
+func (j *Job) Ctx() context.Context {
+	return j.ctx
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/svc"
+)
+
+func main() {
+	fmt.Println(svc.Plain("42"))
+}
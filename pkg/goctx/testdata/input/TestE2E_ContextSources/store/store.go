@@ -0,0 +1,12 @@
+package store
+
+import "errors"
+
+// Load reads the value stored under id.
+func Load(id string) (string, error) {
+	if id == "" {
+		return "", errors.New("empty id")
+	}
+
+	return "value of " + id, nil
+}
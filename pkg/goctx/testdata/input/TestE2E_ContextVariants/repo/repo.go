@@ -0,0 +1,42 @@
+package repo
+
+import "context"
+
+// Repo loads records.
+type Repo struct{}
+
+// Fetch loads id.
+func (r *Repo) Fetch(id string) (string, error) {
+	return r.FetchContext(context.Background(), id)
+}
+
+// FetchContext loads id with ctx.
+func (r *Repo) FetchContext(ctx context.Context, id string) (string, error) {
+	return id, ctx.Err()
+}
+
+// Fetcher loads records.
+type Fetcher interface {
+	Fetch(id string) (string, error)
+	FetchContext(ctx context.Context, id string) (string, error)
+}
+
+// Get is not paired with GetContext, which takes another parameter type.
+func Get(id string) string {
+	return id
+}
+
+// GetContext gets id.
+func GetContext(ctx context.Context, id int) string {
+	return ""
+}
+
+// Load loads id.
+func Load(id string) string {
+	return id
+}
+
+// LoadContext is implemented with Load.
+func LoadContext(ctx context.Context, id string) string {
+	return Load(id)
+}
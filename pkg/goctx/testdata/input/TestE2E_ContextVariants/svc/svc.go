@@ -0,0 +1,35 @@
+package svc
+
+import (
+	"context"
+
+	"example.com/e2e/repo"
+)
+
+// Sync fetches with its ctx.
+func Sync(ctx context.Context, r *repo.Repo, f repo.Fetcher) error {
+	if _, err := r.Fetch("a"); err != nil {
+		return err
+	}
+	_, err := f.Fetch("b") // through the interface
+	_ = repo.Get("c")
+	_ = repo.Load("d")
+	return err
+}
+
+// Prime fetches before and after it sets up its ctx.
+func Prime(r *repo.Repo) string {
+	_, _ = r.Fetch("a")
+	ctx := context.Background()
+	_, _ = r.Fetch("b")
+
+	return repo.LoadContext(ctx, "c")
+}
+
+// Warm fetches without a ctx.
+func Warm(r *repo.Repo) {
+	_, _ = r.Fetch("a")
+	helper()
+}
+
+func helper() {}
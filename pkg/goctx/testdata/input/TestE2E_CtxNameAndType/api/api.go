@@ -0,0 +1,20 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"example.com/e2e/appctx"
+	"example.com/e2e/svc"
+	"example.com/e2e/web"
+)
+
+// Serve handles a request.
+func Serve(r *web.Request) string {
+	return svc.Fetch(1) + svc.Audit(appctx.New(r.Context()), 2)
+}
+
+// Handle serves a row over net/http.
+func Handle(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.WriteString(w, svc.Fetch(3))
+}
@@ -0,0 +1,24 @@
+package appctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Ctx is a context carrying the request logger.
+type Ctx interface {
+	context.Context
+	Logger() *slog.Logger
+}
+
+type ctx struct {
+	context.Context
+	logger *slog.Logger
+}
+
+func (c ctx) Logger() *slog.Logger { return c.logger }
+
+// New wraps parent with the default logger.
+func New(parent context.Context) Ctx {
+	return ctx{Context: parent, logger: slog.Default()}
+}
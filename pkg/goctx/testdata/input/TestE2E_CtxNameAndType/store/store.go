@@ -0,0 +1,6 @@
+package store
+
+// Load loads a row.
+func Load(id int) string {
+	return "row"
+}
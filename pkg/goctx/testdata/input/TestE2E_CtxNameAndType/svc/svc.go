@@ -0,0 +1,24 @@
+package svc
+
+import (
+	"context"
+
+	"example.com/e2e/appctx"
+	"example.com/e2e/store"
+)
+
+// Fetch loads a row.
+func Fetch(id int) string {
+	return store.Load(id)
+}
+
+// Audit loads a row and logs with the request logger.
+func Audit(c appctx.Ctx, id int) string {
+	c.Logger().Info("audit", "id", id)
+	return store.Load(id)
+}
+
+// Refresh reloads a row in the background.
+func Refresh(ctx context.Context, id int) string {
+	return Fetch(id)
+}
@@ -0,0 +1,13 @@
+package web
+
+import "context"
+
+// Request is an incoming request.
+type Request struct {
+	ctx context.Context
+}
+
+// Context returns the request's context.
+func (r *Request) Context() context.Context {
+	return r.ctx
+}
@@ -0,0 +1,7 @@
+module example.com/e2e
+
+go 1.21
+
+require golang.org/x/sync v0.0.0
+
+replace golang.org/x/sync => ./stubs/sync
@@ -0,0 +1,71 @@
+package work
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func handle(item string) error {
+	return nil
+}
+
+// Process handles the items concurrently.
+func Process(items []string) error {
+	gctx := "taken"
+	_ = gctx
+	// fan out, at most four at a time
+	var g errgroup.Group // workers
+	g.SetLimit(4)
+	for _, item := range items {
+		g.Go(func() error {
+			return handle(item)
+		})
+	}
+	return g.Wait()
+}
+
+// Notify already has a ctx; only the group of handle calls is converted.
+func Notify(ctx context.Context, items []string) error {
+	var audit errgroup.Group
+	audit.Go(func() error {
+		return ctx.Err()
+	})
+	var eg errgroup.Group
+	eg.Go(func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return handle("notify")
+	})
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return audit.Wait()
+}
+
+// Shared hands its group out, so it stays a zero value.
+func Shared(items []string) error {
+	var g errgroup.Group
+	g.Go(func() error {
+		return handle(items[0])
+	})
+	wait(&g)
+	return nil
+}
+
+func wait(g *errgroup.Group) {
+	_ = g.Wait()
+}
+
+// Late declares its ctx after the group, which therefore stays a zero value.
+func Late() error {
+	var g errgroup.Group
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.Go(func() error {
+		return handle("late")
+	})
+	_ = ctx
+	return g.Wait()
+}
@@ -0,0 +1,12 @@
+package main
+
+import "fmt"
+
+func work() string {
+	return "done"
+}
+
+func main() {
+	fmt.Println("starting")
+	fmt.Println(work())
+}
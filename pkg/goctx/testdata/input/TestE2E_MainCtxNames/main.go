@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	tm "time"
+)
+
+var start = tm.Now()
+
+func work() string {
+	return "done"
+}
+
+// signal reports an event.
+func signal(event string) {
+	fmt.Println(event, "after", tm.Since(start))
+}
+
+func main() {
+	cancel := func() { signal("cancelled") }
+	defer cancel()
+	fmt.Println(work())
+}
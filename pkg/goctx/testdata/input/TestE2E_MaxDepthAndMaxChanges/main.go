@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+func leaf() string {
+	return "leaf"
+}
+
+func a() string {
+	return leaf()
+}
+
+func b() string {
+	return a()
+}
+
+func c() string {
+	return b()
+}
+
+func main() {
+	fmt.Println(c())
+}
@@ -0,0 +1,21 @@
+package db
+
+// GetUser loads one user.
+func GetUser(id int) string {
+	return lookup(id)
+}
+
+// ListUsers loads all users, one by one.
+func ListUsers() []string {
+	return []string{GetUser(1), GetUser(2)}
+}
+
+// CountUsers is not a target and must stay untouched.
+func CountUsers() int {
+	return 2
+}
+
+func lookup(id int) string {
+	_ = id
+	return "user"
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/db"
+)
+
+func show() {
+	fmt.Println(db.GetUser(1))
+	fmt.Println(db.ListUsers())
+	fmt.Println(db.CountUsers())
+}
+
+func main() {
+	show()
+}
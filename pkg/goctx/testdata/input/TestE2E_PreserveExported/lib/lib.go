@@ -0,0 +1,20 @@
+package lib
+
+import "example.com/e2e/internal/db"
+
+// Get is part of the public API.
+func Get() string {
+	return db.Query()
+}
+
+// Client is part of the public API.
+type Client struct{}
+
+// Fetch is part of the public API too.
+func (c *Client) Fetch() string {
+	return helper()
+}
+
+func helper() string {
+	return db.Query()
+}
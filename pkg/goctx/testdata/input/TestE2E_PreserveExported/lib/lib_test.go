@@ -0,0 +1,10 @@
+package lib
+
+import "testing"
+
+// The test variant of lib shares lib.go with lib.
+func TestGet(t *testing.T) {
+	if Get() == "" {
+		t.Fatal("empty")
+	}
+}
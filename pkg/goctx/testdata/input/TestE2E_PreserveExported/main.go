@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/internal/db"
+	"example.com/e2e/lib"
+)
+
+func main() {
+	c := &lib.Client{}
+	fmt.Println(lib.Get(), c.Fetch(), db.Cached())
+}
@@ -0,0 +1,8 @@
+package gen
+
+import "example.com/e2e/store"
+
+// Gen is generated code.
+func Gen() string {
+	return store.Get()
+}
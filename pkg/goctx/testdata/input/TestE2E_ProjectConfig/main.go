@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/api"
+	"example.com/e2e/gen"
+	"example.com/e2e/store"
+)
+
+func main() {
+	fmt.Println(api.Handle(), gen.Gen(), store.Get())
+}
@@ -0,0 +1,6 @@
+package store
+
+// Get loads a row.
+func Get() string {
+	return "row"
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"example.com/e2e/svc"
+)
+
+func main() {
+	ctx := context.Background()
+	fmt.Println(svc.Load(ctx, "a"))
+}
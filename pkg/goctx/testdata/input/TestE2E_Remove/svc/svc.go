@@ -0,0 +1,30 @@
+package svc
+
+import (
+	"context"
+
+	"example.com/e2e/store"
+)
+
+// Load loads id.
+func Load(ctx context.Context, id string) string {
+	return store.Get(ctx, id)
+}
+
+// Save still passes its ctx to store.Put.
+func Save(ctx context.Context, id string) error {
+	_ = store.Get(ctx, id)
+	return store.Put(ctx, id)
+}
+
+// Runner runs jobs.
+type Runner interface {
+	Run(ctx context.Context)
+}
+
+type job struct{}
+
+// Run implements Runner, so its signature stays.
+func (job) Run(ctx context.Context) {
+	_ = store.Get(ctx, "job")
+}
@@ -0,0 +1,4 @@
+package a
+
+// Callee should accept ctx and propagate.
+func Callee() {}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"example.com/e2e/a"
+)
+
+// main is the boundary; comment should stay here.
+func main() {
+	a.Caller()
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"log/slog"
+
+	"example.com/e2e/svc"
+)
+
+func main() {
+	slog.Info("starting")
+	svc.Report(nil)
+}
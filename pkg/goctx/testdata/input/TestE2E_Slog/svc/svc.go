@@ -0,0 +1,46 @@
+package svc
+
+import (
+	"context"
+	"log/slog"
+)
+
+func init() {
+	slog.Debug("svc loaded")
+}
+
+type Service struct {
+	log *slog.Logger
+}
+
+// Sync stores every id.
+func (s *Service) Sync(ctx context.Context, ids []string) error {
+	s.log.Info("sync", "ids", len(ids))
+	for _, id := range ids {
+		s.store(id)
+	}
+	return nil
+}
+
+func (s *Service) store(id string) {
+	s.log.With("id", id).Debug("storing")
+	if id == "" {
+		slog.Warn("empty id")
+	}
+}
+
+func describe(args ...any) {
+	slog.Info("describe", args...)
+}
+
+// Report describes ids.
+func Report(ids []string) {
+	describe("count", len(ids))
+}
+
+// Start logs before and after it sets up its context.
+func Start() {
+	slog.Info("starting")
+	ctx := context.Background()
+	slog.InfoContext(ctx, "started")
+}
@@ -0,0 +1,15 @@
+package api
+
+import "example.com/e2e/svc"
+
+type Server struct{}
+
+// Get is part of the public API: a boundary by regular expression.
+func (s *Server) Get() string {
+	return s.fetch()
+}
+
+// fetch is unexported and keeps propagating.
+func (s *Server) fetch() string {
+	return svc.Fetch()
+}
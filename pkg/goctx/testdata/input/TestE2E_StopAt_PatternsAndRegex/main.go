@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/e2e/internal/api"
+	"example.com/e2e/internal/jobs/nightly"
+)
+
+func main() {
+	s := &api.Server{}
+	fmt.Println(s.Get(), nightly.Run())
+}
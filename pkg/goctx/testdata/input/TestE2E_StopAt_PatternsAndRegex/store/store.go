@@ -0,0 +1,6 @@
+package store
+
+// Load is the leaf in need of a context.
+func Load() string {
+	return "value"
+}
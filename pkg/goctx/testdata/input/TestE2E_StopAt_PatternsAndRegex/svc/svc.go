@@ -0,0 +1,8 @@
+package svc
+
+import "example.com/e2e/store"
+
+// Fetch is called from the API and from background jobs.
+func Fetch() string {
+	return store.Load()
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"example.com/e2e/worker"
+)
+
+func main() {
+	ctx := context.Background()
+	w := worker.New(ctx, "poller")
+	if err := w.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Worker polls a queue until its context is done.
+type Worker struct {
+	name string
+	// ctx is the context the worker was started with.
+	ctx      context.Context
+	interval time.Duration
+}
+
+// New returns a worker bound to ctx.
+func New(ctx context.Context, name string) *Worker {
+	return &Worker{
+		name:     name,
+		ctx:      ctx,
+		interval: time.Second,
+	}
+}
+
+// Poll waits for one interval.
+func (w *Worker) Poll() error {
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-time.After(w.interval):
+		return nil
+	}
+}
+
+// Run polls until an error occurs.
+func (w *Worker) Run() error {
+	for {
+		if err := w.Poll(); err != nil {
+			return fmt.Errorf("%s: %w", w.name, err)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	w := Worker{"test", t.Context(), time.Millisecond}
+	if err := w.Poll(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,9 @@
+package legacy
+
+import "context"
+
+// Do does x.
+func Do(x int) int { return DoCtx(context.Background(), x) }
+
+// DoCtx does x under ctx.
+func DoCtx(ctx context.Context, x int) int { return x }
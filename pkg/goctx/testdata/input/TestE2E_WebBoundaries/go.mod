@@ -0,0 +1,15 @@
+module example.com/e2e
+
+go 1.21
+
+require (
+	github.com/gin-gonic/gin v1.0.0
+	github.com/gofiber/fiber/v2 v2.0.0
+	github.com/labstack/echo/v4 v4.0.0
+)
+
+replace (
+	github.com/gin-gonic/gin => ./stubs/gin
+	github.com/gofiber/fiber/v2 => ./stubs/fiber
+	github.com/labstack/echo/v4 => ./stubs/echo
+)
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"net/http"
+
+	"example.com/e2e/handlers"
+)
+
+func main() {
+	handlers.Register(http.NewServeMux(), nil, nil, nil)
+	_ = http.ListenAndServe(":8080", &handlers.Server{})
+}
@@ -0,0 +1,6 @@
+package store
+
+// Load loads a row.
+func Load() string {
+	return "row"
+}
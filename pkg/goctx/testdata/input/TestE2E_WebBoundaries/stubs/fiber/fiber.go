@@ -0,0 +1,16 @@
+// Package fiber is a minimal stand-in for github.com/gofiber/fiber/v2.
+package fiber
+
+import "context"
+
+type Ctx struct {
+	ctx context.Context
+}
+
+func (c *Ctx) UserContext() context.Context {
+	return c.ctx
+}
+
+type App struct{}
+
+func (a *App) Get(path string, handlers ...func(*Ctx) error) {}
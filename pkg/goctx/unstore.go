@@ -0,0 +1,594 @@
+package goctx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// fieldSpec names a struct field as Type.field, optionally qualified by the import path of the
+// package declaring Type: example.com/app/worker.Worker.ctx.
+type fieldSpec struct {
+	pkgPath  string
+	typeName string
+	field    string
+}
+
+func (s fieldSpec) String() string {
+	return s.typeName + "." + s.field
+}
+
+// parseFieldSpec parses [import/path.]Type.field.
+func parseFieldSpec(raw string) (fieldSpec, error) {
+	raw = strings.TrimSpace(raw)
+	rest, field, ok := cutLast(raw, ".")
+	if !ok {
+		return fieldSpec{}, fmt.Errorf("invalid field %q, want Type.field or example.com/pkg.Type.field", raw)
+	}
+	spec := fieldSpec{field: field, typeName: rest}
+	if pkgPath, typeName, ok := cutLast(rest, "."); ok {
+		spec.pkgPath, spec.typeName = pkgPath, typeName
+	}
+	if !token.IsIdentifier(spec.typeName) || !token.IsIdentifier(spec.field) || strings.HasSuffix(spec.pkgPath, "/") {
+		return fieldSpec{}, fmt.Errorf("invalid field %q, want Type.field or example.com/pkg.Type.field", raw)
+	}
+
+	return spec, nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
+
+// storedField is the declaration of the struct field a context is stored in.
+type storedField struct {
+	pkg    *packages.Package
+	file   *ast.File
+	spec   *ast.TypeSpec
+	fields *ast.FieldList
+	field  *ast.Field
+	name   *ast.Ident
+}
+
+// findStoredField finds the declaration of the field named by spec. Test variants share their
+// syntax trees, so the same declaration found twice is one candidate.
+func findStoredField(pkgs []*packages.Package, spec fieldSpec) (*storedField, error) {
+	var found []*storedField
+	for _, pkg := range pkgs {
+		if spec.pkgPath != "" && pkg.PkgPath != spec.pkgPath {
+			continue
+		}
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				ts, ok := n.(*ast.TypeSpec)
+				if !ok || ts.Name.Name != spec.typeName {
+					return true
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return false
+				}
+				for _, field := range st.Fields.List {
+					for _, name := range field.Names {
+						if name.Name == spec.field && !slices.ContainsFunc(found, func(f *storedField) bool { return f.name == name }) {
+							found = append(found, &storedField{pkg: pkg, file: file, spec: ts, fields: st.Fields, field: field, name: name})
+						}
+					}
+				}
+
+				return false
+			})
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no struct type %s with a field %s found", spec.typeName, spec.field)
+	case 1:
+		return found[0], nil
+	}
+	candidates := make([]string, 0, len(found))
+	for _, f := range found {
+		candidates = append(candidates, f.pkg.PkgPath+"."+spec.String())
+	}
+
+	return nil, fmt.Errorf("field %s is ambiguous, qualify it with the import path of its package: %s", spec, strings.Join(candidates, ", "))
+}
+
+// unstoreField migrates the context stored in the struct field named by raw to parameters (see
+// Options.Unstore). It removes the field and the values stored in it, gives every function
+// reading the field a parameter it reads instead, and drops the context parameters of
+// constructors that only stored it. It returns the functions whose callers must now pass a
+// context, and a function removing the imports left unused, to be called once propagation is
+// done.
+func unstoreField(pkgs []*packages.Package, raw string, cp ctxParam, modifiedFiles map[string]bool, changes *changeLog) ([]types.Object, func(), error) {
+	spec, err := parseFieldSpec(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	sf, err := findStoredField(pkgs, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t := sf.pkg.TypesInfo.TypeOf(sf.field.Type); !cp.isContext(t) {
+		return nil, nil, fmt.Errorf("field %s is of type %s, not a %s", spec, types.TypeString(t, nil), cp.noun())
+	}
+	fieldIndex := -1
+	if st, ok := sf.pkg.TypesInfo.TypeOf(sf.spec.Type).(*types.Struct); ok {
+		for i := range st.NumFields() {
+			if st.Field(i).Pos() == sf.name.Pos() {
+				fieldIndex = i
+			}
+		}
+	}
+
+	u := &unstorer{
+		editor:     newEditor(cp, modifiedFiles, changes),
+		spec:       spec,
+		fieldPos:   sf.name.Pos(),
+		typePos:    sf.spec.Name.Pos(),
+		fieldIndex: fieldIndex,
+		readers:    make(map[*ast.FuncDecl]string),
+	}
+	u.noteDecl(sf)
+	u.removeDecl(sf)
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			if err := u.rewriteFile(pkg, file); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for _, w := range u.writers {
+		u.dropUnusedParam(pkgs, w)
+	}
+
+	return u.start, u.tidy, nil
+}
+
+// editor deletes nodes from files and remembers the files it touches, so that the imports of the
+// context type they no longer use can be removed.
+type editor struct {
+	cp            ctxParam
+	modifiedFiles map[string]bool
+	changes       *changeLog
+	touched       map[*ast.File]*token.FileSet
+}
+
+func newEditor(cp ctxParam, modifiedFiles map[string]bool, changes *changeLog) *editor {
+	return &editor{cp: cp, modifiedFiles: modifiedFiles, changes: changes, touched: make(map[*ast.File]*token.FileSet)}
+}
+
+// unstorer holds the state of an unstoreField run.
+type unstorer struct {
+	*editor
+
+	spec       fieldSpec
+	fieldPos   token.Pos
+	typePos    token.Pos
+	fieldIndex int
+
+	readers map[*ast.FuncDecl]string // parameter read instead of the field
+	writers []funcRef                // functions that stored a value in the field
+	start   []types.Object
+}
+
+// funcRef locates a function declaration.
+type funcRef struct {
+	pkg  *packages.Package
+	file *ast.File
+	fn   *ast.FuncDecl
+}
+
+// touch marks file modified and remembers it for tidy.
+func (e *editor) touch(fset *token.FileSet, file *ast.File) {
+	markFileModified(e.modifiedFiles, fset, file)
+	e.touched[file] = fset
+}
+
+// removeDecl removes the field from the struct declaration, with its comments.
+func (u *unstorer) removeDecl(sf *storedField) {
+	fset := sf.pkg.Fset
+	if len(sf.field.Names) > 1 {
+		sf.field.Names = slices.DeleteFunc(sf.field.Names, func(id *ast.Ident) bool { return id == sf.name })
+		u.touch(fset, sf.file)
+
+		return
+	}
+	i := slices.Index(sf.fields.List, sf.field)
+	prevEnd, nextStart := sf.fields.Opening, sf.fields.Closing
+	if i > 0 {
+		prevEnd = sf.fields.List[i-1].End()
+	}
+	if i+1 < len(sf.fields.List) {
+		nextStart = nodeStart(sf.fields.List[i+1], sf.fields.List[i+1].Doc)
+	}
+	end := sf.field.End()
+	if sf.field.Comment != nil {
+		end = sf.field.Comment.End()
+	}
+	u.dropNode(fset, sf.file, nodeStart(sf.field, sf.field.Doc), end, prevEnd, nextStart)
+	sf.fields.List = slices.Delete(sf.fields.List, i, i+1)
+	u.touch(fset, sf.file)
+}
+
+// noteDecl records the removal of the field for the declaration of its struct type.
+func (u *unstorer) noteDecl(sf *storedField) {
+	for _, decl := range sf.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && slices.Contains(gen.Specs, ast.Spec(sf.spec)) {
+			u.changes.noteType(gen, "remove field %s", u.spec)
+		}
+	}
+}
+
+// nodeStart is the position of node, or of its doc comment when it has one.
+func nodeStart(node ast.Node, doc *ast.CommentGroup) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+
+	return node.Pos()
+}
+
+// dropNode removes the comments of a node spanning from..to that is being deleted from file.
+// When no other node shares its lines (prevEnd and nextStart are the neighbouring nodes or
+// braces), the lines are dropped too, so that no blank line is left in their place.
+func (e *editor) dropNode(fset *token.FileSet, file *ast.File, from, to, prevEnd, nextStart token.Pos) {
+	first, last := fset.Position(from).Line, fset.Position(to).Line
+	whole := fset.Position(prevEnd).Line < first && fset.Position(nextStart).Line > last
+	file.Comments = slices.DeleteFunc(file.Comments, func(cg *ast.CommentGroup) bool {
+		if whole {
+			line := fset.Position(cg.Pos()).Line
+
+			return line >= first && line <= last
+		}
+
+		return cg.Pos() >= from && cg.End() <= to
+	})
+	if whole {
+		e.changes.removeLines(fset.File(file.Pos()).Name(), first, last)
+	}
+}
+
+// rewriteFile replaces reads of the field with a parameter and removes the values stored in it.
+func (u *unstorer) rewriteFile(pkg *packages.Package, file *ast.File) error {
+	info := pkg.TypesInfo
+	var (
+		enclosing *ast.FuncDecl
+		err       error
+	)
+	isField := func(expr ast.Expr) bool {
+		sel, ok := expr.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		selection := info.Selections[sel]
+
+		return selection != nil && selection.Kind() == types.FieldVal && selection.Obj().Pos() == u.fieldPos
+	}
+	astutil.Apply(file, func(c *astutil.Cursor) bool {
+		if err != nil {
+			return false
+		}
+		switch n := c.Node().(type) {
+		case *ast.FuncDecl:
+			enclosing = n
+		case *ast.AssignStmt:
+			if !slices.ContainsFunc(n.Lhs, isField) {
+				return true
+			}
+			u.addWriter(pkg, file, enclosing)
+			if len(n.Lhs) == 1 && c.Index() >= 0 {
+				u.removeStmt(pkg.Fset, file, c)
+
+				return false
+			}
+			for i, lhs := range n.Lhs {
+				if isField(lhs) {
+					n.Lhs[i] = &ast.Ident{Name: "_", NamePos: lhs.Pos()}
+				}
+			}
+		case *ast.CompositeLit:
+			if u.removeElts(pkg.Fset, file, info, n) {
+				u.addWriter(pkg, file, enclosing)
+			}
+		case *ast.SelectorExpr:
+			if !isField(n) {
+				return true
+			}
+			if enclosing == nil {
+				err = fmt.Errorf("cannot move %s to a parameter: it is read outside a function at %s", u.spec, pkg.Fset.Position(n.Pos()))
+
+				return false
+			}
+			c.Replace(&ast.Ident{Name: u.readerParam(pkg, file, enclosing), NamePos: n.Pos()})
+			u.touch(pkg.Fset, file)
+
+			return false
+		}
+
+		return true
+	}, func(c *astutil.Cursor) bool {
+		if c.Node() == enclosing {
+			enclosing = nil
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// readerParam gives fn a parameter to read instead of the field, once, and returns its name.
+// Functions without one seed the traversal of their callers.
+func (u *unstorer) readerParam(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl) string {
+	if name, ok := u.readers[fn]; ok {
+		return name
+	}
+	had := functionHasContextParam(fn, pkg.TypesInfo, u.cp)
+	if ensureFuncHasCtxParam(pkg.Fset, file, fn, pkg.TypesInfo, u.cp, true) {
+		u.changes.note(fn, "add %s parameter (replaces field %s)", u.cp.noun(), u.spec)
+	}
+	name := u.cp.name
+	for _, field := range fn.Type.Params.List {
+		if len(field.Names) > 0 && field.Names[0].Name != "_" && isContextType(pkg.TypesInfo, field.Type, u.cp) {
+			name = field.Names[0].Name
+
+			break
+		}
+	}
+	u.changes.note(fn, "read %s instead of field %s", name, u.spec)
+	if !had {
+		if obj := pkg.TypesInfo.Defs[fn.Name]; obj != nil {
+			u.start = append(u.start, obj)
+		}
+	}
+	u.readers[fn] = name
+
+	return name
+}
+
+// addWriter records fn as storing a value in the field.
+func (u *unstorer) addWriter(pkg *packages.Package, file *ast.File, fn *ast.FuncDecl) {
+	u.touch(pkg.Fset, file)
+	if fn == nil || slices.ContainsFunc(u.writers, func(w funcRef) bool { return w.fn == fn }) {
+		return
+	}
+	u.changes.note(fn, "stop storing %s in field %s", u.cp.noun(), u.spec)
+	u.writers = append(u.writers, funcRef{pkg: pkg, file: file, fn: fn})
+}
+
+// removeStmt deletes the statement at c, with its comments.
+func (e *editor) removeStmt(fset *token.FileSet, file *ast.File, c *astutil.Cursor) {
+	var (
+		list       []ast.Stmt
+		open, stop token.Pos
+	)
+	switch parent := c.Parent().(type) {
+	case *ast.BlockStmt:
+		list, open, stop = parent.List, parent.Lbrace, parent.Rbrace
+	case *ast.CaseClause:
+		list, open, stop = parent.Body, parent.Colon, parent.End()
+	case *ast.CommClause:
+		list, open, stop = parent.Body, parent.Colon, parent.End()
+	}
+	if i := c.Index(); i < len(list) {
+		prevEnd, nextStart := open, stop
+		if i > 0 {
+			prevEnd = list[i-1].End()
+		}
+		if i+1 < len(list) {
+			nextStart = list[i+1].Pos()
+		}
+		e.dropNode(fset, file, c.Node().Pos(), lineEnd(fset, file, c.Node().End()), prevEnd, nextStart)
+	}
+	c.Delete()
+}
+
+// lineEnd extends end over a comment trailing it on the same line.
+func lineEnd(fset *token.FileSet, file *ast.File, end token.Pos) token.Pos {
+	line := fset.Position(end).Line
+	for _, cg := range file.Comments {
+		if cg.Pos() >= end && fset.Position(cg.Pos()).Line == line {
+			return cg.End()
+		}
+	}
+
+	return end
+}
+
+// removeElts removes the value of the field from lit if lit is a literal of the struct type.
+func (u *unstorer) removeElts(fset *token.FileSet, file *ast.File, info *types.Info, lit *ast.CompositeLit) bool {
+	t := info.TypeOf(lit)
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Origin().Obj().Pos() != u.typePos {
+		return false
+	}
+	for i, elt := range lit.Elts {
+		kv, keyed := elt.(*ast.KeyValueExpr)
+		if keyed && !isIdentNamed(kv.Key, u.spec.field) || !keyed && i != u.fieldIndex {
+			continue
+		}
+		prevEnd, nextStart := lit.Lbrace, lit.Rbrace
+		if i > 0 {
+			prevEnd = lit.Elts[i-1].End()
+		}
+		if i+1 < len(lit.Elts) {
+			nextStart = lit.Elts[i+1].Pos()
+		}
+		u.dropNode(fset, file, elt.Pos(), lineEnd(fset, file, elt.End()), prevEnd, nextStart)
+		lit.Elts = slices.Delete(lit.Elts, i, i+1)
+
+		return true
+	}
+
+	return false
+}
+
+// dropUnusedParam removes the context parameter of a function that stored it in the field and
+// no longer uses it, and the argument from every call. A function also used as a value keeps
+// its signature.
+func (u *unstorer) dropUnusedParam(pkgs []*packages.Package, w funcRef) {
+	info := w.pkg.TypesInfo
+	obj := info.Defs[w.fn.Name]
+	if obj == nil || w.fn.Body == nil || w.fn.Type.Params == nil {
+		return
+	}
+	index := 0
+	var param *ast.Field
+	for _, field := range w.fn.Type.Params.List {
+		if len(field.Names) == 1 && field.Names[0].Name != "_" && isContextType(info, field.Type, u.cp) {
+			param = field
+
+			break
+		}
+		index += max(1, len(field.Names))
+	}
+	if param == nil || identUsed(w.fn.Body, param.Names[0].Name) {
+		return
+	}
+
+	type callRef struct {
+		pkg  *packages.Package
+		file *ast.File
+		call *ast.CallExpr
+	}
+	var calls []callRef
+	isObj := func(o types.Object) bool { return o != nil && o.Pos() == obj.Pos() && o.Name() == obj.Name() }
+	seen := make(map[*ast.File]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			callees := make(map[*ast.Ident]bool)
+			valueUse := false
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr:
+					if called, _ := resolveCalled(pkg.TypesInfo, n.Fun); isObj(called) {
+						if len(n.Args) <= index || n.Ellipsis.IsValid() {
+							valueUse = true
+						}
+						calls = append(calls, callRef{pkg: pkg, file: file, call: n})
+						callees[calleeIdent(n.Fun)] = true
+					}
+				case *ast.Ident:
+					if isObj(pkg.TypesInfo.Uses[n]) && !callees[n] {
+						valueUse = true
+					}
+				}
+
+				return !valueUse
+			})
+			if valueUse {
+				return
+			}
+		}
+	}
+
+	w.fn.Type.Params.List = slices.DeleteFunc(w.fn.Type.Params.List, func(f *ast.Field) bool { return f == param })
+	u.changes.note(w.fn, "drop %s parameter, no longer stored in field %s", u.cp.noun(), u.spec)
+	for _, c := range calls {
+		c.call.Args = slices.Delete(c.call.Args, index, index+1)
+		u.changes.note(enclosingFuncDecl(c.file, c.call), "drop %s argument of %s", u.cp.noun(), w.fn.Name.Name)
+		u.touch(c.pkg.Fset, c.file)
+	}
+}
+
+// calleeIdent returns the identifier naming the function called by fun.
+func calleeIdent(fun ast.Expr) *ast.Ident {
+	for {
+		switch x := fun.(type) {
+		case *ast.IndexExpr:
+			fun = x.X
+		case *ast.IndexListExpr:
+			fun = x.X
+		case *ast.SelectorExpr:
+			return x.Sel
+		case *ast.Ident:
+			return x
+		default:
+			return nil
+		}
+	}
+}
+
+// identUsed reports whether body refers to name, not counting selected fields and methods.
+func identUsed(body ast.Node, name string) bool {
+	used := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			used = used || identUsed(n.X, name)
+
+			return false
+		case *ast.Ident:
+			used = used || n.Name == name
+		}
+
+		return !used
+	})
+
+	return used
+}
+
+// tidy removes the imports of the parameter type from the touched files that no longer use them.
+func (e *editor) tidy() {
+	path := "context"
+	if e.cp.typ != nil {
+		path = e.cp.path
+	}
+	for file, fset := range e.touched {
+		name := importName(file, path)
+		if name == "" || name == "_" || name == "." {
+			continue
+		}
+		used := slices.ContainsFunc(file.Decls, func(decl ast.Decl) bool {
+			gen, ok := decl.(*ast.GenDecl)
+
+			return (!ok || gen.Tok != token.IMPORT) && identUsed(decl, name)
+		})
+		if !used {
+			astutil.DeleteImport(fset, file, path)
+		}
+	}
+}
+
+// importName returns the name file refers to the package at path by, or "" when it does not
+// import it. The package name is assumed to be the last element of path.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err != nil || p != path {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+
+	return ""
+}
@@ -0,0 +1,25 @@
+package goctx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldSpec(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]fieldSpec{
+		"Worker.ctx":                            {typeName: "Worker", field: "ctx"},
+		"example.com/app/worker.Worker.ctx":     {pkgPath: "example.com/app/worker", typeName: "Worker", field: "ctx"},
+		" example.com/app/worker.v2.Worker.ctx": {pkgPath: "example.com/app/worker.v2", typeName: "Worker", field: "ctx"},
+	} {
+		got, err := parseFieldSpec(raw)
+		require.NoError(t, err, raw)
+		require.Equal(t, want, got, raw)
+	}
+	for _, raw := range []string{"ctx", "Worker.", ".ctx", "(*Worker).ctx", "example.com/.Worker.ctx"} {
+		_, err := parseFieldSpec(raw)
+		require.ErrorContains(t, err, "want Type.field or example.com/pkg.Type.field", raw)
+	}
+}
@@ -0,0 +1,107 @@
+package goctx
+
+import (
+	"go/ast"
+	"go/types"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// contextVariant is the context-aware sibling of a function of the loaded packages, such as
+// FetchContext(ctx, id) for Fetch(id).
+type contextVariant struct {
+	name     string // FetchContext
+	fullName string // types.Func.FullName of the variant
+}
+
+// contextVariants finds the Foo/FooContext pairs declared in pkgs, keyed by the full name of
+// Foo: package-level functions, and methods of named and interface types, whose FooContext
+// sibling takes a context first and then exactly the parameters of Foo, and returns the same
+// results. Generic functions are skipped.
+func contextVariants(pkgs []*packages.Package, cp ctxParam) map[string]contextVariant {
+	variants := make(map[string]contextVariant)
+	add := func(fn *types.Func, lookup func(string) *types.Func) {
+		if strings.HasSuffix(fn.Name(), SuffixContext) {
+			return
+		}
+		variant := lookup(fn.Name() + SuffixContext)
+		if variant == nil || !isContextVariant(fn.Type().(*types.Signature), variant.Type().(*types.Signature), cp) {
+			return
+		}
+		slog.Debug("context variant found", slog.String("func", fn.FullName()), slog.String("variant", variant.Name()))
+		variants[fn.FullName()] = contextVariant{name: variant.Name(), fullName: variant.FullName()}
+	}
+
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			switch obj := scope.Lookup(name).(type) {
+			case *types.Func:
+				add(obj, func(name string) *types.Func {
+					f, _ := scope.Lookup(name).(*types.Func)
+
+					return f
+				})
+			case *types.TypeName:
+				named, ok := obj.Type().(*types.Named)
+				if !ok || obj.IsAlias() {
+					continue
+				}
+				methods := make(map[string]*types.Func)
+				if iface, ok := named.Underlying().(*types.Interface); ok {
+					for i := range iface.NumExplicitMethods() {
+						methods[iface.ExplicitMethod(i).Name()] = iface.ExplicitMethod(i)
+					}
+				} else {
+					for i := range named.NumMethods() {
+						methods[named.Method(i).Name()] = named.Method(i)
+					}
+				}
+				for _, m := range methods {
+					add(m, func(name string) *types.Func { return methods[name] })
+				}
+			}
+		}
+	}
+
+	return variants
+}
+
+// isContextVariant reports whether variant has the signature of fn with a context parameter
+// prepended.
+func isContextVariant(fn, variant *types.Signature, cp ctxParam) bool {
+	if fn.TypeParams().Len() > 0 || variant.TypeParams().Len() > 0 || fn.RecvTypeParams().Len() > 0 {
+		return false
+	}
+	params, vparams := fn.Params(), variant.Params()
+	if vparams.Len() != params.Len()+1 || variant.Variadic() != fn.Variadic() || !cp.isContext(vparams.At(0).Type()) {
+		return false
+	}
+	for i := range params.Len() {
+		if !types.Identical(params.At(i).Type(), vparams.At(i+1).Type()) {
+			return false
+		}
+	}
+
+	return types.Identical(fn.Results(), variant.Results())
+}
+
+// useContextVariant rewrites call, a call to a function with a context-aware sibling, to call the
+// sibling with ctxName, the context in scope at the call, unless fn is the sibling itself, which
+// may well be implemented with the call. It reports whether call was rewritten.
+func useContextVariant(pkg *packages.Package, fn *ast.FuncDecl, call *ast.CallExpr, callee *types.Func, variant contextVariant, ctxName string, changes *changeLog) bool {
+	if obj, ok := pkg.TypesInfo.Defs[fn.Name].(*types.Func); ok && obj.FullName() == variant.fullName {
+		return false
+	}
+	redirectCallToCompat(call, callee.Name())
+	call.Args = append([]ast.Expr{ast.NewIdent(ctxName)}, call.Args...)
+	changes.note(fn, "call %s instead of %s", variant.name, callee.Name())
+	slog.Debug("call redirected to context variant", slog.String("func", funcKeyOfDecl(pkg, fn)), slog.String("variant", variant.fullName))
+
+	return true
+}